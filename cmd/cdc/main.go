@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"fq/internal/database/storage/replication"
+	"fq/internal/database/storage/wal"
+	"fq/internal/network"
+	"fq/internal/tools"
+)
+
+const (
+	loggerTimestampFormat = "2006-01-02 15:04:05"
+)
+
+// cdc prints counter changes from the WAL of a master as JSON lines to stdout.
+func main() {
+	address := flag.String("address", ":1946", "Replication address of the master")
+	fromLSN := flag.Uint64("from_lsn", 1, "LSN to start reading from")
+	limit := flag.Int("limit", 0, "Max number of WAL records read at once, 0 means the master default")
+	pollInterval := flag.Duration("poll_interval", time.Second, "Interval of polling for new changes")
+	idleTimeout := flag.Duration("idle_timeout", time.Minute, "Idle timeout for connection")
+	maxMessageSizeStr := flag.String("max_message_size", "16MB", "Max message size for connection")
+	flag.Parse()
+
+	logger := consoleLogger()
+	maxMessageSize, err := tools.ParseSize(*maxMessageSizeStr)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse max message size")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	encoder := json.NewEncoder(os.Stdout)
+	nextLSN := *fromLSN
+
+	for ctx.Err() == nil {
+		// the master closes idle connections, so the consumer reconnects and continues from the next LSN
+		nextLSN, err = consume(ctx, *address, maxMessageSize, *idleTimeout, nextLSN, *limit, *pollInterval, encoder)
+		if errors.Is(err, wal.ErrLSNTooOld) {
+			logger.Fatal().Err(err).Msg("changes were removed from the master")
+		}
+
+		if err != nil && ctx.Err() == nil {
+			logger.Warn().Err(err).Uint64("next_lsn", nextLSN).Msg("failed to read changes, reconnecting")
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(*pollInterval):
+			}
+		}
+	}
+
+	logger.Info().Uint64("next_lsn", nextLSN).Msg("stopped")
+}
+
+func consume(
+	ctx context.Context,
+	address string,
+	maxMessageSize int,
+	idleTimeout time.Duration,
+	fromLSN uint64,
+	limit int,
+	pollInterval time.Duration,
+	encoder *json.Encoder,
+) (uint64, error) {
+	client, err := network.NewTCPClient(address, maxMessageSize, idleTimeout)
+	if err != nil {
+		return fromLSN, err
+	}
+	defer client.Close()
+
+	consumer, err := replication.NewCDCConsumer(client, fromLSN, limit)
+	if err != nil {
+		return fromLSN, err
+	}
+
+	for {
+		changes, err := consumer.Read(ctx)
+		if err != nil {
+			return consumer.NextLSN(), err
+		}
+
+		for _, change := range changes {
+			if err := encoder.Encode(change); err != nil {
+				return consumer.NextLSN(), err
+			}
+		}
+
+		if len(changes) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return consumer.NextLSN(), nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func consoleLogger() *zerolog.Logger {
+	consoleWriter := zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: loggerTimestampFormat}
+	logger := zerolog.New(consoleWriter).
+		With().
+		Timestamp().
+		Logger()
+
+	return &logger
+}
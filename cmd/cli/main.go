@@ -98,10 +98,30 @@ func main() {
 			}
 
 			fmt.Printf("%s\t\t\t\tElapsed: %s\n", parseResp(response), elapsed.String())
+
+			if isSubscribeCommand(request) && bytes.HasPrefix(response, []byte("ok|")) {
+				fmt.Println("Receiving events... (press Ctrl+C to exit)")
+				receiveEvents(client, logger)
+			}
 		}()
 	}
 }
 
+// receiveEvents prints messages pushed by the server until the connection is closed.
+func receiveEvents(client *network.TCPClient, logger *zerolog.Logger) {
+	for {
+		messages, err := client.Receive(context.Background())
+		if err != nil {
+			logger.Fatal().Err(err).Msg("connection was closed")
+		}
+
+		// pushed messages end with a newline, several of them may be read at once
+		for _, message := range bytes.Split(bytes.TrimSuffix(messages, []byte("\n")), []byte("\n")) {
+			fmt.Println(parseResp(message))
+		}
+	}
+}
+
 func consoleLogger() *zerolog.Logger {
 	consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: loggerTimestampFormat}
 	logger := zerolog.New(consoleWriter).
@@ -120,6 +140,15 @@ func parseResp(response []byte) aurora.Value {
 		return aurora.Green("[fq]> " + data)
 	}
 
+	if status == "push" {
+		return aurora.Cyan("[fq]> " + data)
+	}
+
+	// errors are err|<code>|<message>
+	if code, message, found := strings.Cut(data, "|"); found {
+		return aurora.Red("[fq]> " + code + ": " + message)
+	}
+
 	return aurora.Red("[fq]> " + data)
 }
 
@@ -127,3 +156,8 @@ func isWatchCommand(request string) bool {
 	upperRequest := strings.ToUpper(strings.TrimSpace(request))
 	return strings.HasPrefix(upperRequest, "WATCH ")
 }
+
+func isSubscribeCommand(request string) bool {
+	upperRequest := strings.ToUpper(strings.TrimSpace(request))
+	return strings.HasPrefix(upperRequest, "SUBSCRIBE ")
+}
@@ -16,16 +16,25 @@ const (
 	WALSyncCommitOn  = "on"
 	WALSyncCommitOff = "off"
 
+	// slaves reject writes of clients or forward them to the master
+	SlaveWritesReject  = "reject"
+	SlaveWritesForward = "forward"
+
+	MaxHistorySize = 1000
+
 	configDefaultFilePath = "config.yml"
 )
 
 type Config struct {
-	Engine      EngineConfig      `yaml:"engine"`
-	WAL         *WALConfig        `yaml:"wal"`
-	Network     NetworkConfig     `yaml:"network"`
-	Logging     LoggingConfig     `yaml:"logging"`
-	Dump        DumpConfig        `yaml:"dump"`
-	Replication ReplicationConfig `yaml:"replication"`
+	Engine      EngineConfig            `yaml:"engine"`
+	WAL         *WALConfig              `yaml:"wal"`
+	Network     NetworkConfig           `yaml:"network"`
+	RESP        *NetworkConfig          `yaml:"resp"`
+	HTTP        *HTTPConfig             `yaml:"http"`
+	Logging     LoggingConfig           `yaml:"logging"`
+	Dump        DumpConfig              `yaml:"dump"`
+	Replication ReplicationConfig       `yaml:"replication"`
+	Policies    map[string]PolicyConfig `yaml:"policies"`
 }
 
 //nolint:tagliatelle // it's ok
@@ -40,6 +49,15 @@ func (cfg NetworkConfig) ParseMaxMessageSize() (int, error) {
 	return tools.ParseSize(cfg.MaxMessageSize)
 }
 
+// HTTPConfig configures the HTTP/JSON API, it is disabled without the section.
+//
+//nolint:tagliatelle // it's ok
+type HTTPConfig struct {
+	Address         string        `yaml:"address"`
+	MaxBodySize     string        `yaml:"max_body_size"`
+	MaxWatchTimeout time.Duration `yaml:"max_watch_timeout"`
+}
+
 type LoggingConfig struct {
 	Level string `yaml:"level"`
 }
@@ -47,6 +65,7 @@ type LoggingConfig struct {
 type EngineConfig struct {
 	Type          string        `yaml:"type"`
 	CleanInterval time.Duration `yaml:"clean_interval"`
+	HistorySize   int           `yaml:"history_size"`
 }
 
 type WALConfig struct {
@@ -66,6 +85,19 @@ type ReplicationConfig struct {
 	ReplicaType   string        `yaml:"replica_type"`
 	MasterAddress string        `yaml:"master_address"`
 	SyncInterval  time.Duration `yaml:"sync_interval"`
+	// SlaveWrites is "reject" (default) or "forward", ForwardAddress is the client address of the master
+	SlaveWrites    string `yaml:"slave_writes"`
+	ForwardAddress string `yaml:"forward_address"`
+	// ListenAddress is the replication address of a slave after REPLICA PROMOTE,
+	// a master listens on MasterAddress
+	ListenAddress string `yaml:"listen_address"`
+}
+
+// PolicyConfig is a named capping applied by the HIT command.
+type PolicyConfig struct {
+	Window string `yaml:"window"`
+	Limit  int64  `yaml:"limit"`
+	Mode   string `yaml:"mode"`
 }
 
 func Init() (Config, error) {
@@ -107,6 +139,7 @@ func validate(cfg *Config) error {
 	err := validation.ValidateStruct(&cfg.Engine,
 		validation.Field(&cfg.Engine.Type, validation.Required, validation.In("in_memory")),
 		validation.Field(&cfg.Engine.CleanInterval, validation.Required),
+		validation.Field(&cfg.Engine.HistorySize, validation.Min(0), validation.Max(MaxHistorySize)),
 	)
 	if err != nil {
 		return fmt.Errorf("validate engine section: %w", err)
@@ -130,6 +163,33 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("validate network section: %w", err)
 	}
 
+	if cfg.RESP != nil {
+		err = validation.ValidateStruct(cfg.RESP,
+			validation.Field(&cfg.RESP.Address, validation.Required),
+		)
+		if err != nil {
+			return fmt.Errorf("validate resp section: %w", err)
+		}
+	}
+
+	if cfg.HTTP != nil {
+		err = validation.ValidateStruct(cfg.HTTP,
+			validation.Field(&cfg.HTTP.Address, validation.Required),
+		)
+		if err != nil {
+			return fmt.Errorf("validate http section: %w", err)
+		}
+	}
+
+	err = validation.ValidateStruct(&cfg.Replication,
+		validation.Field(&cfg.Replication.SlaveWrites, validation.In(SlaveWritesReject, SlaveWritesForward)),
+		validation.Field(&cfg.Replication.ForwardAddress,
+			validation.When(cfg.Replication.SlaveWrites == SlaveWritesForward, validation.Required)),
+	)
+	if err != nil {
+		return fmt.Errorf("validate replication section: %w", err)
+	}
+
 	if cfg.WAL != nil {
 		err = validation.ValidateStruct(cfg.WAL,
 			validation.Field(&cfg.WAL.FlushingBatchLength, validation.Required),
@@ -143,6 +203,16 @@ func validate(cfg *Config) error {
 		}
 	}
 
+	for name, policy := range cfg.Policies {
+		err = validation.ValidateStruct(&policy,
+			validation.Field(&policy.Window, validation.Required),
+			validation.Field(&policy.Limit, validation.Min(0)),
+		)
+		if err != nil {
+			return fmt.Errorf("validate policy %q: %w", name, err)
+		}
+	}
+
 	err = validation.ValidateStruct(&cfg.Logging,
 		validation.Field(&cfg.Logging.Level, validation.Required,
 			validation.In("debug", "info", "warn", "error")),
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"fq/internal/database/compute"
+)
+
+// CommandHandler executes a query of a command.
+type CommandHandler func(ctx context.Context, query compute.Query) Reply
+
+var errInvalidCommandHandler = errors.New("command handler is invalid")
+
+// RegisterCommand adds a command with its handler to the registry, which dispatches its queries.
+// A write command logs its WAL records by LogQuery and declares compute.Command.Replay, which applies them.
+// Commands must be registered before queries are handled.
+func (d *Database) RegisterCommand(command compute.Command, handler CommandHandler) error {
+	if handler == nil {
+		return errInvalidCommandHandler
+	}
+
+	command.Handler = handler.handler()
+
+	return d.commands.Register(command)
+}
+
+// LogQuery writes the WAL record of a query of a registered write command, which has already changed its state.
+// The arguments of the record are encoded by compute.Command.Encode, the results are given by the handler.
+func (d *Database) LogQuery(ctx context.Context, query compute.Query, results []int64) error {
+	command, found := d.commands.CommandByID(query.CommandID())
+	if !found {
+		return compute.ErrInvalidCommand
+	}
+
+	args := query.Arguments()
+	if command.Encode != nil {
+		args = command.Encode(query)
+	}
+
+	return d.storageLayer.Log(ctx, command.ID, args, results)
+}
+
+// handler adapts the handler to the registry, which passes replies on without knowing their type.
+func (h CommandHandler) handler() compute.Handler {
+	return func(ctx context.Context, query compute.Query) compute.Reply {
+		return h(ctx, query)
+	}
+}
+
+// setBuiltinHandlers sets the handlers of builtin commands in the registry.
+func (d *Database) setBuiltinHandlers() error {
+	for id, handler := range d.builtinHandlers() {
+		if err := d.commands.SetHandler(id, handler.handler()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Database) builtinHandlers() map[compute.CommandID]CommandHandler {
+	return map[compute.CommandID]CommandHandler{
+		compute.IncrCommandID:      d.handleIncrQuery,
+		compute.GetCommandID:       d.handleGetQuery,
+		compute.DelCommandID:       d.handleDelQuery,
+		compute.MsgSizeCommandID:   d.handleMsgSizeQuery,
+		compute.MDelCommandID:      d.handleMDelQuery,
+		compute.WatchCommandID:     d.handleWatchQuery,
+		compute.IncrByCommandID:    d.handleIncrByQuery,
+		compute.CapIncrCommandID:   d.handleCapIncrQuery,
+		compute.MCapIncrCommandID:  d.handleMCapIncrQuery,
+		compute.ThrottleCommandID:  d.handleThrottleQuery,
+		compute.HistCommandID:      d.handleHistQuery,
+		compute.PolicyCommandID:    d.handlePolicyQuery,
+		compute.HitCommandID:       d.handleHitQuery,
+		compute.SubscribeCommandID: d.handleSubscribeQuery,
+		compute.MGetCommandID:      d.handleMGetQuery,
+		compute.MIncrCommandID:     d.handleMIncrQuery,
+		compute.CommandsCommandID:  d.handleCommandsQuery,
+		compute.HelpCommandID:      d.handleHelpQuery,
+		compute.ReplicaCommandID:   d.handleReplicaQuery,
+	}
+}
+
+// handleCommandsQuery lists commands with their classes, e.g. INCR:write.
+func (d *Database) handleCommandsQuery(context.Context, compute.Query) Reply {
+	commands := d.commands.Commands()
+
+	values := make([]ReplyValue, 0, len(commands))
+	for _, command := range commands {
+		if len(command.Subcommands) == 0 {
+			values = append(values, commandClassReplyValue(command.Name, command.Class))
+
+			continue
+		}
+
+		subcommands := make([]string, 0, len(command.Subcommands))
+		for subcommand := range command.Subcommands {
+			subcommands = append(subcommands, subcommand)
+		}
+		sort.Strings(subcommands)
+
+		for _, subcommand := range subcommands {
+			values = append(values, commandClassReplyValue(command.Name+" "+subcommand, command.Subcommands[subcommand]))
+		}
+	}
+
+	return Reply{Values: values, Array: true}
+}
+
+func commandClassReplyValue(name string, class compute.CommandClass) ReplyValue {
+	return TupleReplyValue(StringReplyValue(name), StringReplyValue(class.String()))
+}
+
+// handleHelpQuery returns the usage of a command, or usages of all commands without arguments.
+func (d *Database) handleHelpQuery(_ context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+	if len(arguments) == 0 {
+		commands := d.commands.Commands()
+
+		values := make([]ReplyValue, 0, len(commands))
+		for _, command := range commands {
+			values = append(values, StringReplyValue(command.Usage))
+		}
+
+		return Reply{Values: values, Array: true}
+	}
+
+	command, found := d.commands.Command(strings.ToUpper(arguments[0]))
+	if !found {
+		return makeErrorReply(compute.ErrInvalidCommand)
+	}
+
+	return Reply{Values: []ReplyValue{StringReplyValue(command.Usage)}}
+}
@@ -0,0 +1,138 @@
+package database_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+	"fq/internal/database/compute"
+	"fq/internal/database/storage"
+	inmemory "fq/internal/database/storage/engine/in-memory"
+	"fq/internal/database/storage/wal"
+)
+
+const echoCommandID compute.CommandID = 1000
+
+func TestDatabase_RegisterCommand(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := inmemory.NewEngine(inmemory.HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	strg, err := storage.NewStorage(engine, nil, nil, nil, &logger, time.Minute, time.Minute, false)
+	require.NoError(t, err)
+
+	commands := compute.NewRegistry()
+	computeLayer := compute.NewCompute(compute.NewParser(&logger), compute.NewAnalyzer(commands, &logger), &logger)
+	db := database.NewDatabase(computeLayer, strg, commands, &logger, 4096)
+
+	echo := compute.Command{
+		ID:       echoCommandID,
+		Name:     "ECHO",
+		Usage:    "ECHO <message>",
+		Validate: compute.ArgumentsNumber(1, 0),
+	}
+
+	err = db.RegisterCommand(echo, func(_ context.Context, query compute.Query) database.Reply {
+		return database.Reply{Values: []database.ReplyValue{database.StringReplyValue(query.Arguments()[0])}}
+	})
+	require.NoError(t, err)
+
+	err = db.RegisterCommand(compute.Command{ID: 1001, Name: compute.IncrCommand}, func(context.Context, compute.Query) database.Reply {
+		return database.Reply{}
+	})
+	require.ErrorIs(t, err, compute.ErrCommandAlreadyExists)
+
+	ctx := context.Background()
+	tests := map[string]struct {
+		query    string
+		response string
+	}{
+		"registered command": {
+			query:    "ECHO hello",
+			response: "ok|hello",
+		},
+		"registered command with invalid arguments": {
+			query:    "ECHO",
+			response: "err|E_ARGS|invalid arguments",
+		},
+		"help of a registered command": {
+			query:    "HELP echo",
+			response: "ok|ECHO <message>",
+		},
+		"help of a builtin command": {
+			query:    "HELP GET",
+			response: "ok|GET <key> <capping> [mode]",
+		},
+		"help of an unknown command": {
+			query:    "HELP TRUNCATE",
+			response: "err|E_ARGS|invalid command",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.response, db.HandleQuery(ctx, test.query))
+		})
+	}
+
+	response := db.HandleQuery(ctx, "COMMANDS")
+	require.True(t, strings.HasPrefix(response, "ok|"))
+	require.Contains(t, strings.Split(strings.TrimPrefix(response, "ok|"), ";"), "ECHO:read")
+	require.Contains(t, strings.Split(strings.TrimPrefix(response, "ok|"), ";"), "INCR:write")
+	require.Contains(t, strings.Split(strings.TrimPrefix(response, "ok|"), ";"), "POLICY GET:read")
+	require.Contains(t, strings.Split(strings.TrimPrefix(response, "ok|"), ";"), "POLICY SET:write")
+}
+
+func TestDatabase_LogQuery(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := inmemory.NewEngine(inmemory.HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	directory := t.TempDir()
+	fsReader := wal.NewFSReader(directory, &logger)
+	writeAheadLog := wal.NewWAL(wal.NewFSWriter(directory, 1<<20, &logger), fsReader, nil, time.Millisecond, 100, directory, &logger)
+
+	strg, err := storage.NewStorage(engine, writeAheadLog, nil, nil, &logger, time.Minute, time.Minute, true)
+	require.NoError(t, err)
+	writeAheadLog.Start()
+
+	commands := compute.NewRegistry()
+	computeLayer := compute.NewCompute(compute.NewParser(&logger), compute.NewAnalyzer(commands, &logger), &logger)
+	db := database.NewDatabase(computeLayer, strg, commands, &logger, 4096)
+
+	note := compute.Command{
+		ID:       echoCommandID,
+		Name:     "NOTE",
+		Class:    compute.WriteCommand,
+		Validate: compute.ArgumentsNumber(1, 0),
+		// the record carries the version of its format
+		Encode: func(query compute.Query) []string {
+			return append([]string{"v1"}, query.Arguments()...)
+		},
+	}
+
+	err = db.RegisterCommand(note, func(ctx context.Context, query compute.Query) database.Reply {
+		if err := db.LogQuery(ctx, query, []int64{1}); err != nil {
+			return database.Reply{Err: err}
+		}
+
+		return database.Reply{Values: []database.ReplyValue{database.IntReplyValue(1)}}
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "ok|1", db.HandleQuery(context.Background(), "NOTE hello"))
+	writeAheadLog.Shutdown()
+
+	logs, err := fsReader.ReadLogs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, uint32(echoCommandID), logs[0].CommandId)
+	require.Equal(t, []string{"v1", "hello"}, logs[0].Arguments)
+	require.Equal(t, []int64{1}, logs[0].Results)
+}
@@ -2,44 +2,39 @@ package compute
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"strings"
 
 	"github.com/rs/zerolog"
 )
 
-const (
-	incrQueryArgumentsNumber    = 2
-	getQueryArgumentsNumber     = 2
-	delQueryArgumentsNumber     = 2
-	msgSizeQueryArgumentsNumber = 0
-	mdelQueryArgumentsNumber    = -2
-	watchQueryArgumentsNumber   = 2
-)
+// QueryError is an error of a query text: an invalid symbol, an unknown command or invalid arguments.
+type QueryError struct {
+	message string
+}
 
-var queryArgumentsNumber = map[CommandID]int{
-	IncrCommandID:    incrQueryArgumentsNumber,
-	GetCommandID:     getQueryArgumentsNumber,
-	DelCommandID:     delQueryArgumentsNumber,
-	MsgSizeCommandID: msgSizeQueryArgumentsNumber,
-	MDelCommandID:    mdelQueryArgumentsNumber,
-	WatchCommandID:   watchQueryArgumentsNumber,
+func (e *QueryError) Error() string {
+	return e.message
 }
 
 var (
-	ErrInvalidSymbol    = errors.New("invalid symbol")
-	ErrInvalidCommand   = errors.New("invalid command")
-	ErrInvalidArguments = errors.New("invalid arguments")
+	ErrInvalidSymbol    error = &QueryError{message: "invalid symbol"}
+	ErrInvalidCommand   error = &QueryError{message: "invalid command"}
+	ErrInvalidArguments error = &QueryError{message: "invalid arguments"}
 )
 
+type commandRegistry interface {
+	Command(name string) (Command, bool)
+}
+
 type Analyzer struct {
-	logger *zerolog.Logger
+	commands commandRegistry
+	logger   *zerolog.Logger
 }
 
-func NewAnalyzer(logger *zerolog.Logger) *Analyzer {
+func NewAnalyzer(commands commandRegistry, logger *zerolog.Logger) *Analyzer {
 	return &Analyzer{
-		logger: logger,
+		commands: commands,
+		logger:   logger,
 	}
 }
 
@@ -48,25 +43,16 @@ func (a *Analyzer) AnalyzeQuery(_ context.Context, tokens []string) (Query, erro
 		return Query{}, ErrInvalidCommand
 	}
 
-	command := strings.ToUpper(tokens[0])
-	commandID := CommandNameToCommandID(command)
-	if commandID == UnknownCommandID {
+	command, found := a.commands.Command(strings.ToUpper(tokens[0]))
+	if !found {
 		return Query{}, ErrInvalidCommand
 	}
 
-	query := NewQuery(commandID, tokens[1:])
-	argumentsNumber := queryArgumentsNumber[commandID]
-	switch {
-	case argumentsNumber >= 0:
-		if len(query.Arguments()) != argumentsNumber {
-			return Query{}, ErrInvalidArguments
-		}
-	case argumentsNumber == -2:
-		if len(query.Arguments())%2 != 0 {
-			return Query{}, ErrInvalidArguments
+	query := NewQuery(command.ID, tokens[1:])
+	if command.Validate != nil {
+		if err := command.Validate(query.Arguments()); err != nil {
+			return Query{}, err
 		}
-	default:
-		return Query{}, fmt.Errorf("unknown arguments count setting: %d for command %d", argumentsNumber, commandID)
 	}
 
 	if a.logger.GetLevel() == zerolog.DebugLevel {
@@ -10,6 +10,8 @@ import (
 	"fq/internal/database/compute"
 )
 
+const pingCommandID compute.CommandID = 1000
+
 func TestAnalyzeQuery(t *testing.T) {
 	tests := map[string]struct {
 		tokens []string
@@ -40,6 +42,50 @@ func TestAnalyzeQuery(t *testing.T) {
 			tokens: []string{"MDEL", "key1", "600", "key2"},
 			err:    compute.ErrInvalidArguments,
 		},
+		"invalid number arguments for mget query": {
+			tokens: []string{"MGET", "key1", "600", "key2"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"invalid number arguments for mincr query": {
+			tokens: []string{"MINCR"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"invalid number arguments for capincr query": {
+			tokens: []string{"CAPINCR", "key", "60"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"invalid number arguments for mcapincr query": {
+			tokens: []string{"MCAPINCR", "key"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"invalid number arguments for incrby query": {
+			tokens: []string{"INCRBY", "key", "60"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"invalid number arguments for throttle query": {
+			tokens: []string{"THROTTLE", "key", "10", "60"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"invalid number arguments for hist query": {
+			tokens: []string{"HIST", "key", "3600"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"invalid number arguments for policy query": {
+			tokens: []string{"POLICY", "GET"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"invalid number arguments for hit query": {
+			tokens: []string{"HIT", "campaign_daily"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"invalid number arguments for subscribe query": {
+			tokens: []string{"SUBSCRIBE", "THRESHOLD", "user:*", "86400"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"too many arguments for incr query": {
+			tokens: []string{"INCR", "key", "60", "SLIDING", "AT", "1", "2"},
+			err:    compute.ErrInvalidArguments,
+		},
 		"invalid number arguments for message size query": {
 			tokens: []string{"MSGSIZE", "key"},
 			err:    compute.ErrInvalidArguments,
@@ -48,6 +94,14 @@ func TestAnalyzeQuery(t *testing.T) {
 			tokens: []string{"INCR", "key", "60"},
 			query:  compute.NewQuery(compute.IncrCommandID, []string{"key", "60"}),
 		},
+		"valid incr query with window mode": {
+			tokens: []string{"INCR", "key", "60", "SLIDING"},
+			query:  compute.NewQuery(compute.IncrCommandID, []string{"key", "60", "SLIDING"}),
+		},
+		"valid incr query with timestamp": {
+			tokens: []string{"INCR", "key", "60", "SLIDING", "AT", "1760000000"},
+			query:  compute.NewQuery(compute.IncrCommandID, []string{"key", "60", "SLIDING", "AT", "1760000000"}),
+		},
 		"valid get query": {
 			tokens: []string{"GET", "key", "60"},
 			query:  compute.NewQuery(compute.GetCommandID, []string{"key", "60"}),
@@ -60,15 +114,82 @@ func TestAnalyzeQuery(t *testing.T) {
 			tokens: []string{"MDEL", "key1", "60", "key2", "60"},
 			query:  compute.NewQuery(compute.MDelCommandID, []string{"key1", "60", "key2", "60"}),
 		},
+		"valid mget query": {
+			tokens: []string{"MGET", "key1", "60", "key2", "3600"},
+			query:  compute.NewQuery(compute.MGetCommandID, []string{"key1", "60", "key2", "3600"}),
+		},
+		"valid mincr query": {
+			tokens: []string{"MINCR", "key1", "60", "key2", "3600"},
+			query:  compute.NewQuery(compute.MIncrCommandID, []string{"key1", "60", "key2", "3600"}),
+		},
+		"valid capincr query": {
+			tokens: []string{"CAPINCR", "key", "60", "5"},
+			query:  compute.NewQuery(compute.CapIncrCommandID, []string{"key", "60", "5"}),
+		},
+		"valid mcapincr query": {
+			tokens: []string{"MCAPINCR", "key", "3600:3", "86400:10"},
+			query:  compute.NewQuery(compute.MCapIncrCommandID, []string{"key", "3600:3", "86400:10"}),
+		},
+		"valid incrby query": {
+			tokens: []string{"INCRBY", "key", "60", "5"},
+			query:  compute.NewQuery(compute.IncrByCommandID, []string{"key", "60", "5"}),
+		},
+		"valid throttle query": {
+			tokens: []string{"THROTTLE", "key", "10", "60", "5"},
+			query:  compute.NewQuery(compute.ThrottleCommandID, []string{"key", "10", "60", "5"}),
+		},
+		"valid throttle query with quantity": {
+			tokens: []string{"THROTTLE", "key", "10", "60", "5", "2"},
+			query:  compute.NewQuery(compute.ThrottleCommandID, []string{"key", "10", "60", "5", "2"}),
+		},
+		"valid hist query": {
+			tokens: []string{"HIST", "key", "3600", "24"},
+			query:  compute.NewQuery(compute.HistCommandID, []string{"key", "3600", "24"}),
+		},
+		"valid policy query": {
+			tokens: []string{"POLICY", "SET", "campaign_daily", "86400", "5"},
+			query:  compute.NewQuery(compute.PolicyCommandID, []string{"SET", "campaign_daily", "86400", "5"}),
+		},
+		"valid hit query": {
+			tokens: []string{"HIT", "campaign_daily", "user1"},
+			query:  compute.NewQuery(compute.HitCommandID, []string{"campaign_daily", "user1"}),
+		},
+		"valid subscribe query": {
+			tokens: []string{"SUBSCRIBE", "THRESHOLD", "user:*", "86400", "5"},
+			query:  compute.NewQuery(compute.SubscribeCommandID, []string{"THRESHOLD", "user:*", "86400", "5"}),
+		},
 		"valid message size query": {
 			tokens: []string{"MSGSIZE"},
 			query:  compute.NewQuery(compute.MsgSizeCommandID, []string{}),
 		},
+		"valid help query": {
+			tokens: []string{"HELP", "incr"},
+			query:  compute.NewQuery(compute.HelpCommandID, []string{"incr"}),
+		},
+		"invalid number arguments for commands query": {
+			tokens: []string{"COMMANDS", "INCR"},
+			err:    compute.ErrInvalidArguments,
+		},
+		"valid registered command query": {
+			tokens: []string{"ping", "hello"},
+			query:  compute.NewQuery(pingCommandID, []string{"hello"}),
+		},
+		"invalid number arguments for registered command query": {
+			tokens: []string{"PING", "hello", "world"},
+			err:    compute.ErrInvalidArguments,
+		},
 	}
 
 	ctx := context.Background()
 	logger := zerolog.Nop()
-	analyzer := compute.NewAnalyzer(&logger)
+	registry := compute.NewRegistry()
+	require.NoError(t, registry.Register(compute.Command{
+		ID:       pingCommandID,
+		Name:     "PING",
+		Validate: compute.ArgumentsNumber(0, 1),
+	}))
+
+	analyzer := compute.NewAnalyzer(registry, &logger)
 
 	for name, test := range tests {
 		test := test
@@ -10,37 +10,226 @@ const (
 	MsgSizeCommandID
 	MDelCommandID
 	WatchCommandID
+	CapIncrCommandID
+	MCapIncrCommandID
+	IncrByCommandID
+	ThrottleCommandID
+	HistCommandID
+	PolicyCommandID
+	HitCommandID
+	SubscribeCommandID
+	MGetCommandID
+	MIncrCommandID
+	CommandsCommandID
+	HelpCommandID
+	ReplicaCommandID
 )
 
 var (
-	UnknownCommand = "UNKNOWN"
-	IncrCommand    = "INCR"
-	GetCommand     = "GET"
-	DelCommand     = "DEL"
-	MsgSizeCommand = "MSGSIZE"
-	MDelCommand    = "MDEL"
-	WatchCommand   = "WATCH"
+	UnknownCommand   = "UNKNOWN"
+	IncrCommand      = "INCR"
+	GetCommand       = "GET"
+	DelCommand       = "DEL"
+	MsgSizeCommand   = "MSGSIZE"
+	MDelCommand      = "MDEL"
+	WatchCommand     = "WATCH"
+	CapIncrCommand   = "CAPINCR"
+	MCapIncrCommand  = "MCAPINCR"
+	IncrByCommand    = "INCRBY"
+	ThrottleCommand  = "THROTTLE"
+	HistCommand      = "HIST"
+	PolicyCommand    = "POLICY"
+	HitCommand       = "HIT"
+	SubscribeCommand = "SUBSCRIBE"
+	MGetCommand      = "MGET"
+	MIncrCommand     = "MINCR"
+	CommandsCommand  = "COMMANDS"
+	HelpCommand      = "HELP"
+	ReplicaCommand   = "REPLICA"
 )
 
-var commandNamesToID = map[string]CommandID{
-	UnknownCommand: UnknownCommandID,
-	IncrCommand:    IncrCommandID,
-	GetCommand:     GetCommandID,
-	DelCommand:     DelCommandID,
-	MsgSizeCommand: MsgSizeCommandID,
-	MDelCommand:    MDelCommandID,
-	WatchCommand:   WatchCommandID,
+// subcommands of the POLICY command
+var (
+	PolicySetSubcommand = "SET"
+	PolicyGetSubcommand = "GET"
+	PolicyDelSubcommand = "DEL"
+)
+
+// subcommands of the REPLICA command
+var (
+	ReplicaPromoteSubcommand = "PROMOTE"
+	ReplicaOfSubcommand      = "OF"
+)
+
+// subcommands of the SUBSCRIBE command
+var (
+	SubscribeThresholdSubcommand = "THRESHOLD"
+)
+
+// builtinCommands are commands of the database, NewRegistry registers them.
+var builtinCommands = []Command{
+	{
+		ID:       IncrCommandID,
+		Name:     IncrCommand,
+		Class:    WriteCommand,
+		Usage:    "INCR <key> <capping> [mode] [AT <timestamp>]",
+		Validate: ArgumentsNumber(2, 3),
+	},
+	{
+		ID:       GetCommandID,
+		Name:     GetCommand,
+		Class:    ReadCommand,
+		Usage:    "GET <key> <capping> [mode]",
+		Validate: ArgumentsNumber(2, 1),
+	},
+	{
+		ID:       DelCommandID,
+		Name:     DelCommand,
+		Class:    WriteCommand,
+		Usage:    "DEL <key> <capping>",
+		Validate: ArgumentsNumber(2, 0),
+	},
+	{
+		ID:       MsgSizeCommandID,
+		Name:     MsgSizeCommand,
+		Class:    ReadCommand,
+		Usage:    "MSGSIZE",
+		Validate: ArgumentsNumber(0, 0),
+	},
+	{
+		ID:       MDelCommandID,
+		Name:     MDelCommand,
+		Class:    WriteCommand,
+		Usage:    "MDEL <key> <capping> [<key> <capping> ...]",
+		Validate: ArgumentPairs(),
+	},
+	{
+		ID:       WatchCommandID,
+		Name:     WatchCommand,
+		Class:    ReadCommand,
+		Usage:    "WATCH <key> <capping>",
+		Validate: ArgumentsNumber(2, 0),
+	},
+	{
+		ID:       CapIncrCommandID,
+		Name:     CapIncrCommand,
+		Class:    WriteCommand,
+		Usage:    "CAPINCR <key> <capping> <limit> [mode] [AT <timestamp>]",
+		Validate: ArgumentsNumber(3, 3),
+	},
+	{
+		ID:       MCapIncrCommandID,
+		Name:     MCapIncrCommand,
+		Class:    WriteCommand,
+		Usage:    "MCAPINCR <key> <capping>:<limit> [<capping>:<limit> ...] [mode] [AT <timestamp>]",
+		Validate: MinArgumentsNumber(2),
+	},
+	{
+		ID:       IncrByCommandID,
+		Name:     IncrByCommand,
+		Class:    WriteCommand,
+		Usage:    "INCRBY <key> <capping> <delta> [mode] [AT <timestamp>]",
+		Validate: ArgumentsNumber(3, 3),
+	},
+	{
+		ID:       ThrottleCommandID,
+		Name:     ThrottleCommand,
+		Class:    WriteCommand,
+		Usage:    "THROTTLE <key> <rate> <period> <burst> [quantity]",
+		Validate: ArgumentsNumber(4, 1),
+	},
+	{
+		ID:       HistCommandID,
+		Name:     HistCommand,
+		Class:    ReadCommand,
+		Usage:    "HIST <key> <capping> <n>",
+		Validate: ArgumentsNumber(3, 0),
+	},
+	{
+		ID:    PolicyCommandID,
+		Name:  PolicyCommand,
+		Class: WriteCommand,
+		Subcommands: map[string]CommandClass{
+			PolicySetSubcommand: WriteCommand,
+			PolicyGetSubcommand: ReadCommand,
+			PolicyDelSubcommand: WriteCommand,
+		},
+		Usage:    "POLICY SET <name> <capping> <limit> [mode] | POLICY GET <name> | POLICY DEL <name>",
+		Validate: MinArgumentsNumber(2),
+	},
+	{
+		ID:       HitCommandID,
+		Name:     HitCommand,
+		Class:    WriteCommand,
+		Usage:    "HIT <policy> <key> [mode] [AT <timestamp>]",
+		Validate: ArgumentsNumber(2, 2),
+	},
+	{
+		ID:       SubscribeCommandID,
+		Name:     SubscribeCommand,
+		Class:    ReadCommand,
+		Usage:    "SUBSCRIBE THRESHOLD <key-pattern> <capping> <limit>",
+		Validate: ArgumentsNumber(4, 0),
+	},
+	{
+		ID:       MGetCommandID,
+		Name:     MGetCommand,
+		Class:    ReadCommand,
+		Usage:    "MGET <key> <capping> [<key> <capping> ...]",
+		Validate: ArgumentPairs(),
+	},
+	{
+		ID:       MIncrCommandID,
+		Name:     MIncrCommand,
+		Class:    WriteCommand,
+		Usage:    "MINCR <key> <capping> [<key> <capping> ...]",
+		Validate: ArgumentPairs(),
+	},
+	{
+		ID:       CommandsCommandID,
+		Name:     CommandsCommand,
+		Class:    ReadCommand,
+		Usage:    "COMMANDS",
+		Validate: ArgumentsNumber(0, 0),
+	},
+	{
+		ID:       HelpCommandID,
+		Name:     HelpCommand,
+		Class:    ReadCommand,
+		Usage:    "HELP [command]",
+		Validate: ArgumentsNumber(0, 1),
+	},
+	{
+		ID:       ReplicaCommandID,
+		Name:     ReplicaCommand,
+		Class:    AdminCommand,
+		Usage:    "REPLICA PROMOTE | REPLICA OF <master-address>",
+		Validate: ArgumentsNumber(1, 1),
+	},
 }
 
 func (c CommandID) Int() int {
 	return int(c)
 }
 
-func CommandNameToCommandID(command string) CommandID {
-	id, found := commandNamesToID[command]
-	if !found {
-		return UnknownCommandID
+// CommandIDToCommandName returns the name of a builtin command, UnknownCommand for unknown IDs.
+func CommandIDToCommandName(id CommandID) string {
+	for _, command := range builtinCommands {
+		if command.ID == id {
+			return command.Name
+		}
+	}
+
+	return UnknownCommand
+}
+
+// CommandNameToCommandID returns the ID of a builtin command, UnknownCommandID for unknown names.
+func CommandNameToCommandID(name string) CommandID {
+	for _, command := range builtinCommands {
+		if command.Name == name {
+			return command.ID
+		}
 	}
 
-	return id
+	return UnknownCommandID
 }
@@ -13,5 +13,22 @@ func TestCommandNameToCommandID(t *testing.T) {
 	require.Equal(t, compute.GetCommandID, compute.CommandNameToCommandID("GET"))
 	require.Equal(t, compute.DelCommandID, compute.CommandNameToCommandID("DEL"))
 	require.Equal(t, compute.MsgSizeCommandID, compute.CommandNameToCommandID("MSGSIZE"))
+	require.Equal(t, compute.IncrByCommandID, compute.CommandNameToCommandID("INCRBY"))
+	require.Equal(t, compute.CapIncrCommandID, compute.CommandNameToCommandID("CAPINCR"))
+	require.Equal(t, compute.ThrottleCommandID, compute.CommandNameToCommandID("THROTTLE"))
+	require.Equal(t, compute.HistCommandID, compute.CommandNameToCommandID("HIST"))
+	require.Equal(t, compute.PolicyCommandID, compute.CommandNameToCommandID("POLICY"))
+	require.Equal(t, compute.HitCommandID, compute.CommandNameToCommandID("HIT"))
+	require.Equal(t, compute.SubscribeCommandID, compute.CommandNameToCommandID("SUBSCRIBE"))
+	require.Equal(t, compute.MGetCommandID, compute.CommandNameToCommandID("MGET"))
+	require.Equal(t, compute.MIncrCommandID, compute.CommandNameToCommandID("MINCR"))
+	require.Equal(t, compute.CommandsCommandID, compute.CommandNameToCommandID("COMMANDS"))
+	require.Equal(t, compute.HelpCommandID, compute.CommandNameToCommandID("HELP"))
 	require.Equal(t, compute.UnknownCommandID, compute.CommandNameToCommandID("TRUNCATE"))
 }
+
+func TestCommandIDToCommandName(t *testing.T) {
+	require.Equal(t, compute.IncrCommand, compute.CommandIDToCommandName(compute.IncrCommandID))
+	require.Equal(t, compute.MCapIncrCommand, compute.CommandIDToCommandName(compute.MCapIncrCommandID))
+	require.Equal(t, compute.UnknownCommand, compute.CommandIDToCommandName(compute.CommandID(1000)))
+}
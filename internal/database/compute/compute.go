@@ -36,6 +36,20 @@ func (d *Compute) HandleQuery(ctx context.Context, queryStr string) (Query, erro
 		return Query{}, err
 	}
 
+	return d.analyzeTokens(ctx, tokens)
+}
+
+// HandleTokens analyzes a query which is already split into tokens, e.g. decoded from a RESP array.
+// The tokens are checked by the rules of the parser first.
+func (d *Compute) HandleTokens(ctx context.Context, tokens []string) (Query, error) {
+	if err := ValidateTokens(tokens); err != nil {
+		return Query{}, err
+	}
+
+	return d.analyzeTokens(ctx, tokens)
+}
+
+func (d *Compute) analyzeTokens(ctx context.Context, tokens []string) (Query, error) {
 	query, err := d.analyzer.AnalyzeQuery(ctx, tokens)
 	if err != nil {
 		return Query{}, err
@@ -63,3 +63,52 @@ func TestHandleQuery(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, compute.NewQuery(compute.GetCommandID, []string{"key", "60"}), query)
 }
+
+func TestHandleTokens(t *testing.T) {
+	tests := map[string]struct {
+		tokens []string
+		err    error
+	}{
+		"valid tokens": {
+			tokens: []string{"GET", "key", "60"},
+		},
+		"key with whitespace": {
+			tokens: []string{"GET", "user 1", "60"},
+			err:    compute.ErrInvalidSymbol,
+		},
+		"key with newline": {
+			tokens: []string{"INCR", "key\nDEL", "60"},
+			err:    compute.ErrInvalidSymbol,
+		},
+		"key with UTF symbols": {
+			tokens: []string{"GET", "字文下", "60"},
+			err:    compute.ErrInvalidSymbol,
+		},
+		"key with glob symbols": {
+			tokens: []string{"GET", "user:*", "60"},
+			err:    compute.ErrInvalidSymbol,
+		},
+		"key pattern of SUBSCRIBE": {
+			tokens: []string{"SUBSCRIBE", "THRESHOLD", "user:[a-f]?:*", "86400", "5"},
+		},
+	}
+
+	ctx := context.Background()
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			analyzer := mocks.NewQueryAnalyzer(t)
+			if test.err == nil {
+				analyzer.On("AnalyzeQuery", mock.Anything, test.tokens).
+					Return(compute.NewQuery(compute.GetCommandID, test.tokens[1:]), nil)
+			}
+
+			log := zerolog.Nop()
+			comp := compute.NewCompute(mocks.NewQueryParser(t), analyzer, &log)
+
+			_, err := comp.HandleTokens(ctx, test.tokens)
+			require.Equal(t, test.err, err)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package compute
 
 import (
 	"context"
+	"strings"
 
 	"github.com/rs/zerolog"
 )
@@ -23,6 +24,10 @@ func (p *Parser) ParseQuery(_ context.Context, query string) ([]string, error) {
 		return nil, err
 	}
 
+	if err := ValidateTokens(tokens); err != nil {
+		return nil, err
+	}
+
 	if p.logger.GetLevel() == zerolog.DebugLevel {
 		p.logger.Debug().
 			Strs("tokens", tokens).
@@ -40,5 +45,33 @@ func isLetter(symbol byte) bool {
 	return (symbol >= 'a' && symbol <= 'z') ||
 		(symbol >= 'A' && symbol <= 'Z') ||
 		(symbol >= '0' && symbol <= '9') ||
-		(symbol == '_') || (symbol == '-')
+		(symbol == '_') || (symbol == '-') || (symbol == ':') ||
+		(symbol == '@') || (symbol == '/') || (symbol == '+') ||
+		(symbol == '.')
+}
+
+func isGlobSymbol(symbol byte) bool {
+	return symbol == '*' || symbol == '?' || symbol == '[' || symbol == ']'
+}
+
+// ValidateTokens checks the symbols of tokens like the parser does, so tokens which weren't parsed
+// from a query text, e.g. decoded from a RESP array or an HTTP request, obey the same rules.
+// Glob symbols are allowed only in the key pattern of SUBSCRIBE, keys of other commands
+// can't be told from patterns otherwise.
+func ValidateTokens(tokens []string) error {
+	for idx, token := range tokens {
+		pattern := isPatternArgument(tokens, idx)
+		for i := 0; i < len(token); i++ {
+			if !isLetter(token[i]) && !(pattern && isGlobSymbol(token[i])) {
+				return ErrInvalidSymbol
+			}
+		}
+	}
+
+	return nil
+}
+
+// isPatternArgument tells whether the token is the key pattern of SUBSCRIBE THRESHOLD <key-pattern> ...
+func isPatternArgument(tokens []string, idx int) bool {
+	return idx == 2 && strings.EqualFold(tokens[0], SubscribeCommand)
 }
@@ -43,6 +43,34 @@ func TestParse(t *testing.T) {
 			query:  "_set__",
 			tokens: []string{"_set__"},
 		},
+		"query with one token with colons": {
+			query:  "3600:5",
+			tokens: []string{"3600:5"},
+		},
+		"query with calendar window token": {
+			query:  "INCR key 1d@America/Argentina/Buenos_Aires",
+			tokens: []string{"INCR", "key", "1d@America/Argentina/Buenos_Aires"},
+		},
+		"query with key pattern token": {
+			query:  "SUBSCRIBE THRESHOLD user:[a-f]?:* 86400 5",
+			tokens: []string{"SUBSCRIBE", "THRESHOLD", "user:[a-f]?:*", "86400", "5"},
+		},
+		"query with key pattern token in lower case": {
+			query:  "subscribe threshold user:* 86400 5",
+			tokens: []string{"subscribe", "threshold", "user:*", "86400", "5"},
+		},
+		"query with glob symbols in key": {
+			query: "INCR user:* 86400",
+			err:   compute.ErrInvalidSymbol,
+		},
+		"query with glob symbols in SUBSCRIBE capping": {
+			query: "SUBSCRIBE THRESHOLD user:* 864?0 5",
+			err:   compute.ErrInvalidSymbol,
+		},
+		"query with address token": {
+			query:  "REPLICA OF db-1.example.com:1945",
+			tokens: []string{"REPLICA", "OF", "db-1.example.com:1945"},
+		},
 		"query with one token with invalid symbols": {
 			query: ".set#",
 			err:   compute.ErrInvalidSymbol,
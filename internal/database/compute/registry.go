@@ -0,0 +1,233 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidCommandSpec   = errors.New("invalid command spec")
+	ErrCommandAlreadyExists = errors.New("command already exists")
+	ErrCommandNotFound      = errors.New("command not found")
+)
+
+// CommandClass tells whether a command changes the state of the database.
+type CommandClass uint8
+
+const (
+	ReadCommand CommandClass = iota
+	WriteCommand
+	// AdminCommand changes the node rather than its data, e.g. its replication role
+	AdminCommand
+)
+
+func (c CommandClass) String() string {
+	switch c {
+	case WriteCommand:
+		return "write"
+	case AdminCommand:
+		return "admin"
+	default:
+		return "read"
+	}
+}
+
+// ArgumentsValidator checks the arguments of a command, they don't include the command name.
+type ArgumentsValidator func(args []string) error
+
+// Reply is a reply of a command handler. Replies are defined by the database,
+// which the compute layer doesn't depend on, so it only passes them on.
+type Reply interface {
+	String() string
+}
+
+// Handler executes a query of a command.
+type Handler func(ctx context.Context, query Query) Reply
+
+// EncodeFunc makes the arguments of the WAL record of a query, which the ReplayFunc of the command applies.
+type EncodeFunc func(query Query) []string
+
+// ReplayFunc applies a WAL record of a command on recovery and on slaves.
+type ReplayFunc func(lsn uint64, args []string, results []int64) error
+
+// Command declares a command of the database.
+type Command struct {
+	// ID is written to WAL records, so it must not change
+	ID CommandID
+	// Name is the upper case name of the command in queries
+	Name  string
+	Class CommandClass
+	// Subcommands are classes of subcommands, e.g. POLICY GET is a read while POLICY SET is a write
+	Subcommands map[string]CommandClass
+	// Usage is the syntax of the command shown by HELP
+	Usage string
+	// Validate checks the arguments before the command is executed, nil accepts any arguments
+	Validate ArgumentsValidator
+	// Handler executes queries of the command, the database sets handlers of builtin commands
+	Handler Handler
+	// Encode makes the WAL record of a query of a write command, nil logs the arguments of the query as is.
+	// Records of builtin commands are encoded by the storage.
+	Encode EncodeFunc
+	// Replay applies WAL records of the command, the engine sets replays of builtin commands
+	Replay ReplayFunc
+}
+
+// ClassOf returns the class of a query of the command by its arguments, the class of its subcommand if it has one.
+func (c Command) ClassOf(args []string) CommandClass {
+	if len(args) > 0 {
+		if class, found := c.Subcommands[strings.ToUpper(args[0])]; found {
+			return class
+		}
+	}
+
+	return c.Class
+}
+
+// Registry keeps the commands known to the database: the analyzer resolves and validates queries by it,
+// the database dispatches them to their handlers and the engine replays WAL records by their replays.
+type Registry struct {
+	mutex  sync.RWMutex
+	byName map[string]Command
+	byID   map[CommandID]Command
+}
+
+// NewRegistry makes a registry with the builtin commands.
+func NewRegistry() *Registry {
+	registry := &Registry{
+		byName: make(map[string]Command, len(builtinCommands)),
+		byID:   make(map[CommandID]Command, len(builtinCommands)),
+	}
+
+	for _, command := range builtinCommands {
+		registry.byName[command.Name] = command
+		registry.byID[command.ID] = command
+	}
+
+	return registry
+}
+
+// Register adds a command, its name and ID must not be used by other commands.
+func (r *Registry) Register(command Command) error {
+	if command.ID == UnknownCommandID || command.Name == "" || command.Name != strings.ToUpper(command.Name) {
+		return fmt.Errorf("%w: %d %q", ErrInvalidCommandSpec, command.ID, command.Name)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, found := r.byName[command.Name]; found {
+		return fmt.Errorf("%w: %s", ErrCommandAlreadyExists, command.Name)
+	}
+
+	if _, found := r.byID[command.ID]; found {
+		return fmt.Errorf("%w: id %d", ErrCommandAlreadyExists, command.ID)
+	}
+
+	r.byName[command.Name] = command
+	r.byID[command.ID] = command
+
+	return nil
+}
+
+// SetHandler sets the handler of a registered command, e.g. of a builtin one.
+func (r *Registry) SetHandler(id CommandID, handler Handler) error {
+	return r.update(id, func(command *Command) {
+		command.Handler = handler
+	})
+}
+
+// SetReplay sets the replay of WAL records of a registered command, e.g. of a builtin one.
+func (r *Registry) SetReplay(id CommandID, replay ReplayFunc) error {
+	return r.update(id, func(command *Command) {
+		command.Replay = replay
+	})
+}
+
+func (r *Registry) update(id CommandID, fn func(*Command)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	command, found := r.byID[id]
+	if !found {
+		return fmt.Errorf("%w: id %d", ErrCommandNotFound, id)
+	}
+
+	fn(&command)
+	r.byID[id] = command
+	r.byName[command.Name] = command
+
+	return nil
+}
+
+// Command returns a command by its upper case name.
+func (r *Registry) Command(name string) (Command, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	command, found := r.byName[name]
+
+	return command, found
+}
+
+func (r *Registry) CommandByID(id CommandID) (Command, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	command, found := r.byID[id]
+
+	return command, found
+}
+
+// Commands returns all commands sorted by name.
+func (r *Registry) Commands() []Command {
+	r.mutex.RLock()
+	commands := make([]Command, 0, len(r.byName))
+	for _, command := range r.byName {
+		commands = append(commands, command)
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+
+	return commands
+}
+
+// ArgumentsNumber accepts the required number of arguments followed by up to optional ones,
+// e.g. the window mode and AT <timestamp>.
+func ArgumentsNumber(required, optional int) ArgumentsValidator {
+	return func(args []string) error {
+		if len(args) < required || len(args) > required+optional {
+			return ErrInvalidArguments
+		}
+
+		return nil
+	}
+}
+
+// MinArgumentsNumber accepts at least n arguments, e.g. a key followed by one or more cappings.
+func MinArgumentsNumber(n int) ArgumentsValidator {
+	return func(args []string) error {
+		if len(args) < n {
+			return ErrInvalidArguments
+		}
+
+		return nil
+	}
+}
+
+// ArgumentPairs accepts one or more pairs of arguments, e.g. pairs of a key and a capping.
+func ArgumentPairs() ArgumentsValidator {
+	return func(args []string) error {
+		if len(args) == 0 || len(args)%2 != 0 {
+			return ErrInvalidArguments
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,118 @@
+package compute_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database/compute"
+)
+
+func TestRegistryRegister(t *testing.T) {
+	tests := map[string]struct {
+		command compute.Command
+		err     error
+	}{
+		"unknown command id": {
+			command: compute.Command{Name: "PING"},
+			err:     compute.ErrInvalidCommandSpec,
+		},
+		"empty name": {
+			command: compute.Command{ID: 1000},
+			err:     compute.ErrInvalidCommandSpec,
+		},
+		"lower case name": {
+			command: compute.Command{ID: 1000, Name: "ping"},
+			err:     compute.ErrInvalidCommandSpec,
+		},
+		"builtin name": {
+			command: compute.Command{ID: 1000, Name: compute.IncrCommand},
+			err:     compute.ErrCommandAlreadyExists,
+		},
+		"builtin id": {
+			command: compute.Command{ID: compute.IncrCommandID, Name: "PING"},
+			err:     compute.ErrCommandAlreadyExists,
+		},
+		"new command": {
+			command: compute.Command{ID: 1000, Name: "PING", Class: compute.WriteCommand},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			registry := compute.NewRegistry()
+
+			err := registry.Register(test.command)
+			require.ErrorIs(t, err, test.err)
+			if test.err != nil {
+				return
+			}
+
+			command, found := registry.Command(test.command.Name)
+			require.True(t, found)
+			require.Equal(t, test.command.ID, command.ID)
+
+			command, found = registry.CommandByID(test.command.ID)
+			require.True(t, found)
+			require.Equal(t, test.command.Name, command.Name)
+		})
+	}
+}
+
+func TestRegistryCommands(t *testing.T) {
+	registry := compute.NewRegistry()
+	require.NoError(t, registry.Register(compute.Command{ID: 1000, Name: "AAA"}))
+
+	commands := registry.Commands()
+	require.Equal(t, "AAA", commands[0].Name)
+
+	for i := 1; i < len(commands); i++ {
+		require.Less(t, commands[i-1].Name, commands[i].Name)
+	}
+
+	incr, found := registry.Command(compute.IncrCommand)
+	require.True(t, found)
+	require.Equal(t, compute.WriteCommand, incr.Class)
+
+	get, found := registry.Command(compute.GetCommand)
+	require.True(t, found)
+	require.Equal(t, compute.ReadCommand, get.Class)
+
+	policy, found := registry.Command(compute.PolicyCommand)
+	require.True(t, found)
+	require.Equal(t, compute.WriteCommand, policy.ClassOf([]string{"SET", "campaign", "86400", "5"}))
+	require.Equal(t, compute.ReadCommand, policy.ClassOf([]string{"get", "campaign"}))
+	require.Equal(t, compute.WriteCommand, policy.ClassOf([]string{"DEL", "campaign"}))
+	require.Equal(t, compute.WriteCommand, policy.ClassOf(nil))
+}
+
+func TestRegistrySetHandlerAndReplay(t *testing.T) {
+	registry := compute.NewRegistry()
+
+	var replayed uint64
+	require.NoError(t, registry.SetReplay(compute.IncrCommandID, func(lsn uint64, _ []string, _ []int64) error {
+		replayed = lsn
+
+		return nil
+	}))
+	require.NoError(t, registry.SetHandler(compute.IncrCommandID, func(context.Context, compute.Query) compute.Reply {
+		return nil
+	}))
+
+	// the command is updated by its name and by its ID alike
+	incr, found := registry.Command(compute.IncrCommand)
+	require.True(t, found)
+	require.NotNil(t, incr.Handler)
+	require.NoError(t, incr.Replay(7, nil, nil))
+	require.Equal(t, uint64(7), replayed)
+
+	incr, found = registry.CommandByID(compute.IncrCommandID)
+	require.True(t, found)
+	require.NotNil(t, incr.Handler)
+	require.NotNil(t, incr.Replay)
+
+	require.ErrorIs(t, registry.SetHandler(1000, nil), compute.ErrCommandNotFound)
+	require.ErrorIs(t, registry.SetReplay(1000, nil), compute.ErrCommandNotFound)
+}
@@ -63,7 +63,7 @@ func (sm *stateMachine) parse(query string) ([]string, error) {
 		switch {
 		case isWhiteSpace(symbol):
 			sm.processEvent(foundWhiteSpaceEvent, symbol)
-		case isLetter(symbol):
+		case isLetter(symbol) || isGlobSymbol(symbol):
 			sm.processEvent(foundLetterEvent, symbol)
 		default:
 			return nil, ErrInvalidSymbol
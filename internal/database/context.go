@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+)
+
+type txTimeCtxKey struct{}
+
+// ContextWithTxTime sets an explicit time of the event for write queries.
+func ContextWithTxTime(ctx context.Context, txTime TxTime) context.Context {
+	return context.WithValue(ctx, txTimeCtxKey{}, txTime)
+}
+
+// TxTimeFromContext returns the explicit time of the event set by ContextWithTxTime.
+func TxTimeFromContext(ctx context.Context) (TxTime, bool) {
+	txTime, ok := ctx.Value(txTimeCtxKey{}).(TxTime)
+
+	return txTime, ok
+}
+
+// Pusher writes messages initiated by the server to the connection of a query.
+type Pusher interface {
+	Push(message []byte) error
+	// Hold keeps the connection open while it is idle until release is called.
+	Hold() (release func())
+}
+
+type pusherCtxKey struct{}
+
+// ContextWithPusher sets the pusher of the connection, queries pushing messages fail without it.
+func ContextWithPusher(ctx context.Context, pusher Pusher) context.Context {
+	return context.WithValue(ctx, pusherCtxKey{}, pusher)
+}
+
+// PusherFromContext returns the pusher set by ContextWithPusher.
+func PusherFromContext(ctx context.Context) (Pusher, bool) {
+	pusher, ok := ctx.Value(pusherCtxKey{}).(Pusher)
+
+	return pusher, ok
+}
+
+type framingCtxKey struct{}
+
+// ContextWithFraming marks the connection of a query as framed, so its messages may carry pipelines.
+func ContextWithFraming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, framingCtxKey{}, true)
+}
+
+// FramingFromContext reports whether ContextWithFraming marked the connection as framed.
+func FramingFromContext(ctx context.Context) bool {
+	framed, _ := ctx.Value(framingCtxKey{}).(bool)
+
+	return framed
+}
+
+type commitGroupCtxKey struct{}
+
+// ContextWithCommitGroup makes writes of a query add their WAL commits to the group instead of waiting for them.
+func ContextWithCommitGroup(ctx context.Context, group *CommitGroup) context.Context {
+	return context.WithValue(ctx, commitGroupCtxKey{}, group)
+}
+
+// CommitGroupFromContext returns the commit group set by ContextWithCommitGroup.
+func CommitGroupFromContext(ctx context.Context) (*CommitGroup, bool) {
+	group, ok := ctx.Value(commitGroupCtxKey{}).(*CommitGroup)
+
+	return group, ok
+}
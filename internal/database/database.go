@@ -2,11 +2,11 @@ package database
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 
@@ -15,50 +15,133 @@ import (
 
 const (
 	maxKeyLength = 1024
-	maxBatchSize = math.MaxUint32
-	minBatchSize = 1
+	maxPeriod    = math.MaxUint32
+	minPeriod    = 1
+	maxLimit     = math.MaxInt64
+	minLimit     = 0
+	maxDelta     = math.MaxInt64
+	minDelta     = 1
+	minRate      = 1
+	minBurst     = 0
+	minQuantity  = 0
+
+	minHistoryLength = 1
+
+	// minThresholdLimit is the minimal subscription limit, counters start at 0 and can't cross lower ones
+	minThresholdLimit = 1
+
+	// maxTimestampSkew is how far in seconds an explicit event time may be ahead of the server clock
+	maxTimestampSkew = 5
+
+	atOption = "AT"
 )
 
 var (
-	errInternalConfiguration = errors.New("internal configuration error")
-	errBatchSizeNotNumber    = errors.New("batch is not a number")
-	errInvalidBatchSize      = errors.New("invalid batch size")
-	errInvalidArgumentsCount = errors.New("invalid arguments count")
-	errKeyTooLong            = errors.New("key length exceeds maximum")
-	errKeyEmpty              = errors.New("key cannot be empty")
+	errInternalConfiguration   = NewError(ErrorCodeInternal, "internal configuration error")
+	errInvalidArgumentsCount   = NewError(ErrorCodeArgs, "invalid arguments count")
+	errKeyTooLong              = NewError(ErrorCodeArgs, "key length exceeds maximum")
+	errKeyEmpty                = NewError(ErrorCodeArgs, "key cannot be empty")
+	errLimitNotNumber          = NewError(ErrorCodeArgs, "limit is not a number")
+	errInvalidLimit            = NewError(ErrorCodeArgs, "invalid limit")
+	errInvalidCappingLimit     = NewError(ErrorCodeArgs, "capping and limit must be separated by ':'")
+	errDuplicateCapping        = NewError(ErrorCodeArgs, "duplicate capping")
+	errDeltaNotNumber          = NewError(ErrorCodeArgs, "delta is not a number")
+	errInvalidDelta            = NewError(ErrorCodeArgs, "invalid delta")
+	errRateNotNumber           = NewError(ErrorCodeArgs, "rate is not a number")
+	errInvalidRate             = NewError(ErrorCodeArgs, "invalid rate")
+	errPeriodNotNumber         = NewError(ErrorCodeArgs, "period is not a number")
+	errInvalidPeriod           = NewError(ErrorCodeArgs, "invalid period")
+	errBurstNotNumber          = NewError(ErrorCodeArgs, "burst is not a number")
+	errInvalidBurst            = NewError(ErrorCodeArgs, "invalid burst")
+	errQuantityNotNumber       = NewError(ErrorCodeArgs, "quantity is not a number")
+	errInvalidQuantity         = NewError(ErrorCodeArgs, "invalid quantity")
+	errHistoryLengthNotNumber  = NewError(ErrorCodeArgs, "history length is not a number")
+	errInvalidHistoryLength    = NewError(ErrorCodeArgs, "invalid history length")
+	errInvalidPolicySubcommand = NewError(ErrorCodeArgs, "invalid policy subcommand")
+	errInvalidReplicaCommand   = NewError(ErrorCodeArgs, "invalid replica subcommand")
+	errTimestampNotNumber      = NewError(ErrorCodeArgs, "timestamp is not a number")
+	errTimestampInFuture       = NewError(ErrorCodeArgs, "timestamp is in the future")
+	errStaleTimestamp          = NewError(ErrorCodeArgs, "timestamp is older than retained windows")
+	errInvalidSubscription     = NewError(ErrorCodeArgs, "invalid subscription")
+	errPushNotSupported        = NewError(ErrorCodeUnsupported, "connection doesn't support server push")
 )
 
 type computeLayer interface {
 	HandleQuery(context.Context, string) (compute.Query, error)
+	HandleTokens(context.Context, []string) (compute.Query, error)
 }
 
 type storageLayer interface {
 	Incr(ctx context.Context, key BatchKey) (ValueType, error)
+	IncrBy(ctx context.Context, key BatchKey, delta ValueType) (ValueType, error)
+	CapIncr(ctx context.Context, key BatchKey, limit ValueType) (ValueType, bool, error)
+	MCapIncr(ctx context.Context, keys []BatchKey, limits []ValueType) ([]ValueType, bool, error)
+	Throttle(ctx context.Context, key ThrottleKey) (ThrottleResult, error)
 	Get(ctx context.Context, key BatchKey) (ValueType, error)
+	History(ctx context.Context, key BatchKey, n int) ([]HistoryEntry, error)
+	SetPolicy(ctx context.Context, policy Policy) error
+	GetPolicy(ctx context.Context, name string) (Policy, error)
+	DelPolicy(ctx context.Context, name string) (bool, error)
 	Del(ctx context.Context, key BatchKey) (bool, error)
 	MDel(ctx context.Context, keys []BatchKey) ([]bool, error)
+	MGet(ctx context.Context, keys []BatchKey) ([]ValueType, error)
+	MIncr(ctx context.Context, keys []BatchKey) ([]ValueType, error)
 	Watch(ctx context.Context, key BatchKey) (ValueType, error)
+	Log(ctx context.Context, commandID compute.CommandID, args []string, results []int64) error
+	IsReadOnly() bool
+	Promote(ctx context.Context) (Tx, error)
+	ReplicaOf(ctx context.Context, masterAddress string) error
+	SubscribeThreshold(
+		ctx context.Context,
+		pattern string,
+		window Window,
+		limit ValueType,
+	) (<-chan ThresholdEvent, func(), error)
+}
+
+type commandRegistry interface {
+	Register(compute.Command) error
+	SetHandler(compute.CommandID, compute.Handler) error
+	Command(name string) (compute.Command, bool)
+	CommandByID(compute.CommandID) (compute.Command, bool)
+	Commands() []compute.Command
+}
+
+// Forwarder sends a query to the master and returns its response of the native protocol.
+type Forwarder interface {
+	Forward(ctx context.Context, query string) (string, error)
 }
 
 type Database struct {
 	computeLayer   computeLayer
 	storageLayer   storageLayer
+	commands       commandRegistry
+	forwarder      Forwarder
 	logger         *zerolog.Logger
 	maxMessageSize int
 }
 
+// NewDatabase makes a database, the registry must be the one used by the compute layer.
 func NewDatabase(
 	computeLayer computeLayer,
 	storageLayer storageLayer,
+	commands commandRegistry,
 	logger *zerolog.Logger,
 	maxMessageSize int,
 ) *Database {
-	return &Database{
+	db := &Database{
 		computeLayer:   computeLayer,
 		storageLayer:   storageLayer,
+		commands:       commands,
 		logger:         logger,
 		maxMessageSize: maxMessageSize,
 	}
+
+	if err := db.setBuiltinHandlers(); err != nil {
+		logger.Error().Err(err).Msg("failed to set handlers of builtin commands")
+	}
+
+	return db
 }
 
 func (d *Database) HandleQuery(ctx context.Context, queryStr string) string {
@@ -70,111 +153,480 @@ func (d *Database) HandleQuery(ctx context.Context, queryStr string) string {
 
 	// Validate message size
 	if len(queryStr) > d.maxMessageSize {
-		return makeErrorMsg(fmt.Errorf("message size %d exceeds maximum %d", len(queryStr), d.maxMessageSize))
+		return makeErrorReply(fmt.Errorf("%w: %d > %d", ErrMessageTooLarge, len(queryStr), d.maxMessageSize)).String()
 	}
 
 	query, err := d.computeLayer.HandleQuery(ctx, queryStr)
 	if err != nil {
-		return makeErrorMsg(err)
-	}
-
-	switch query.CommandID() {
-	case compute.IncrCommandID:
-		return d.handleIncrQuery(ctx, query)
-	case compute.GetCommandID:
-		return d.handleGetQuery(ctx, query)
-	case compute.DelCommandID:
-		return d.handleDelQuery(ctx, query)
-	case compute.MsgSizeCommandID:
-		return d.handleMsgSizeQuery()
-	case compute.MDelCommandID:
-		return d.handleMDelQuery(ctx, query)
-	case compute.WatchCommandID:
-		return d.handleWatchQuery(ctx, query)
-	default:
+		return makeErrorReply(err).String()
+	}
+
+	return d.execute(ctx, query).String()
+}
+
+// HandleCommand handles a query given as a command and its arguments, e.g. decoded from a RESP array.
+func (d *Database) HandleCommand(ctx context.Context, args []string) Reply {
+	if d.logger.GetLevel() == zerolog.DebugLevel {
+		d.logger.Debug().
+			Strs("command", args).
+			Msg("handling command")
+	}
+
+	query, err := d.computeLayer.HandleTokens(ctx, args)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	return d.execute(ctx, query)
+}
+
+// SetForwarder makes a read-only database forward write commands to the master instead of rejecting them.
+// It must be called before queries are handled.
+func (d *Database) SetForwarder(forwarder Forwarder) {
+	d.forwarder = forwarder
+}
+
+func (d *Database) execute(ctx context.Context, query compute.Query) Reply {
+	command, found := d.commands.CommandByID(query.CommandID())
+	if !found || command.Handler == nil {
 		d.logger.Error().Msg("compute layer is incorrect")
 
-		return makeErrorMsg(errInternalConfiguration)
+		return makeErrorReply(errInternalConfiguration)
+	}
+
+	if d.forwarder != nil && d.storageLayer.IsReadOnly() && command.ClassOf(query.Arguments()) == compute.WriteCommand {
+		return d.forward(ctx, command.Name, query.Arguments())
+	}
+
+	reply, ok := command.Handler(ctx, query).(Reply)
+	if !ok {
+		d.logger.Error().Str("command", command.Name).Msg("handler returned a reply of another type")
+
+		return makeErrorReply(errInternalConfiguration)
 	}
+
+	return reply
 }
 
-func (d *Database) handleIncrQuery(ctx context.Context, query compute.Query) string {
+func (d *Database) handleIncrQuery(ctx context.Context, query compute.Query) Reply {
 	arguments := query.Arguments()
 	key, err := makeBatchKey(arguments[0], arguments[1])
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
+	}
+
+	opts, err := makeWriteOptions(arguments, 2)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	key.Mode = opts.mode
+	if ctx, err = opts.apply(ctx, key.Window); err != nil {
+		return makeErrorReply(err)
 	}
 
 	value, err := d.storageLayer.Incr(ctx, key)
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
+	}
+
+	return makeValueReply(value)
+}
+
+func (d *Database) handleIncrByQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+	key, err := makeBatchKey(arguments[0], arguments[1])
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	delta, err := makeDelta(arguments[2])
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	opts, err := makeWriteOptions(arguments, 3)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	key.Mode = opts.mode
+	if ctx, err = opts.apply(ctx, key.Window); err != nil {
+		return makeErrorReply(err)
 	}
 
-	return makeValueMsg(value)
+	value, err := d.storageLayer.IncrBy(ctx, key, delta)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	return makeValueReply(value)
 }
 
-func (d *Database) handleGetQuery(ctx context.Context, query compute.Query) string {
+func (d *Database) handleCapIncrQuery(ctx context.Context, query compute.Query) Reply {
 	arguments := query.Arguments()
 	key, err := makeBatchKey(arguments[0], arguments[1])
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
+	}
+
+	limit, err := makeLimit(arguments[2])
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	opts, err := makeWriteOptions(arguments, 3)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	key.Mode = opts.mode
+	if ctx, err = opts.apply(ctx, key.Window); err != nil {
+		return makeErrorReply(err)
+	}
+
+	value, allowed, err := d.storageLayer.CapIncr(ctx, key, limit)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	return makeCapReply(allowed, value)
+}
+
+func (d *Database) handleMCapIncrQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+
+	// optional arguments follow the cappings
+	optsIdx := 1
+	for optsIdx < len(arguments) && strings.Contains(arguments[optsIdx], ":") {
+		optsIdx++
+	}
+
+	if optsIdx == 1 {
+		return makeErrorReply(errInvalidArgumentsCount)
+	}
+
+	opts, err := makeWriteOptions(arguments, optsIdx)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	keys, limits, err := makeCappedBatchKeys(arguments[0], arguments[1:optsIdx], opts.mode)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	windows := make([]Window, 0, len(keys))
+	for _, key := range keys {
+		windows = append(windows, key.Window)
+	}
+
+	if ctx, err = opts.apply(ctx, windows...); err != nil {
+		return makeErrorReply(err)
+	}
+
+	values, allowed, err := d.storageLayer.MCapIncr(ctx, keys, limits)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	return makeCapsReply(allowed, values)
+}
+
+func (d *Database) handleThrottleQuery(ctx context.Context, query compute.Query) Reply {
+	key, err := makeThrottleKey(query.Arguments())
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	res, err := d.storageLayer.Throttle(ctx, key)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	return makeThrottleReply(res)
+}
+
+func (d *Database) handleGetQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+	key, err := makeBatchKey(arguments[0], arguments[1])
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	if key.Mode, err = makeWindowMode(arguments, 2); err != nil {
+		return makeErrorReply(err)
 	}
 
 	value, err := d.storageLayer.Get(ctx, key)
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
 	}
 
-	return makeValueMsg(value)
+	return makeValueReply(value)
 }
 
-func (d *Database) handleDelQuery(ctx context.Context, query compute.Query) string {
+func (d *Database) handleHistQuery(ctx context.Context, query compute.Query) Reply {
 	arguments := query.Arguments()
 	key, err := makeBatchKey(arguments[0], arguments[1])
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
+	}
+
+	n, err := strconv.Atoi(arguments[2])
+	if err != nil {
+		return makeErrorReply(errHistoryLengthNotNumber)
+	}
+
+	if n < minHistoryLength {
+		return makeErrorReply(fmt.Errorf("%w: %d (must be at least %d)", errInvalidHistoryLength, n, minHistoryLength))
+	}
+
+	entries, err := d.storageLayer.History(ctx, key, n)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	return makeHistoryReply(entries)
+}
+
+func (d *Database) handlePolicyQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+	subcommand := strings.ToUpper(arguments[0])
+
+	switch {
+	case subcommand == compute.PolicySetSubcommand && (len(arguments) == 4 || len(arguments) == 5):
+		policy, err := makePolicy(arguments[1], arguments[2], arguments[3])
+		if err != nil {
+			return makeErrorReply(err)
+		}
+
+		if policy.Mode, err = makeWindowMode(arguments, 4); err != nil {
+			return makeErrorReply(err)
+		}
+
+		if err := d.storageLayer.SetPolicy(ctx, policy); err != nil {
+			return makeErrorReply(err)
+		}
+
+		return makeBoolReply(true)
+	case subcommand == compute.PolicyGetSubcommand && len(arguments) == 2:
+		policy, err := d.storageLayer.GetPolicy(ctx, arguments[1])
+		if err != nil {
+			return makeErrorReply(err)
+		}
+
+		return makePolicyReply(policy)
+	case subcommand == compute.PolicyDelSubcommand && len(arguments) == 2:
+		res, err := d.storageLayer.DelPolicy(ctx, arguments[1])
+		if err != nil {
+			return makeErrorReply(err)
+		}
+
+		return makeBoolReply(res)
+	case subcommand == compute.PolicySetSubcommand,
+		subcommand == compute.PolicyGetSubcommand,
+		subcommand == compute.PolicyDelSubcommand:
+		return makeErrorReply(errInvalidArgumentsCount)
+	default:
+		return makeErrorReply(fmt.Errorf("%w: %s", errInvalidPolicySubcommand, arguments[0]))
+	}
+}
+
+// handleReplicaQuery changes the replication role: REPLICA PROMOTE returns the last transaction
+// of the promoted node, REPLICA OF <address> makes the node a slave of the master.
+func (d *Database) handleReplicaQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+	subcommand := strings.ToUpper(arguments[0])
+
+	switch {
+	case subcommand == compute.ReplicaPromoteSubcommand && len(arguments) == 1:
+		tx, err := d.storageLayer.Promote(ctx)
+		if err != nil {
+			return makeErrorReply(err)
+		}
+
+		return makeValueReply(ValueType(tx))
+	case subcommand == compute.ReplicaOfSubcommand && len(arguments) == 2:
+		if err := d.storageLayer.ReplicaOf(ctx, arguments[1]); err != nil {
+			return makeErrorReply(err)
+		}
+
+		return Reply{}
+	default:
+		return makeErrorReply(fmt.Errorf("%w: %s", errInvalidReplicaCommand, arguments[0]))
+	}
+}
+
+// handleHitQuery increments the key counter in the policy window if it is below the policy limit.
+func (d *Database) handleHitQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+
+	policy, err := d.storageLayer.GetPolicy(ctx, arguments[0])
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	key, err := makeBatchKey(arguments[1], policy.Window.String())
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	opts, err := makeWriteOptions(arguments, 2)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	key.Mode = policy.Mode
+	if opts.mode != WindowModeDefault {
+		key.Mode = opts.mode
+	}
+
+	if ctx, err = opts.apply(ctx, key.Window); err != nil {
+		return makeErrorReply(err)
+	}
+
+	value, allowed, err := d.storageLayer.CapIncr(ctx, key, policy.Limit)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	return makeCapReply(allowed, value)
+}
+
+func (d *Database) handleDelQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+	key, err := makeBatchKey(arguments[0], arguments[1])
+	if err != nil {
+		return makeErrorReply(err)
 	}
 
 	value, err := d.storageLayer.Del(ctx, key)
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
 	}
 
-	return makeBoolMsg(value)
+	return makeBoolReply(value)
 }
 
-func (d *Database) handleMDelQuery(ctx context.Context, query compute.Query) string {
+func (d *Database) handleMDelQuery(ctx context.Context, query compute.Query) Reply {
 	arguments := query.Arguments()
 	keys, err := makeBatchKeys(arguments)
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
 	}
 
 	values, err := d.storageLayer.MDel(ctx, keys)
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
+	}
+
+	return makeBoolsReply(values)
+}
+
+func (d *Database) handleMGetQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+	keys, err := makeBatchKeys(arguments)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	values, err := d.storageLayer.MGet(ctx, keys)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	return makeValuesReply(values)
+}
+
+func (d *Database) handleMIncrQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+	keys, err := makeBatchKeys(arguments)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	values, err := d.storageLayer.MIncr(ctx, keys)
+	if err != nil {
+		return makeErrorReply(err)
 	}
 
-	return makeBoolsMsg(values)
+	return makeValuesReply(values)
 }
 
-func (d *Database) handleMsgSizeQuery() string {
-	return makeValueMsg(ValueType(d.maxMessageSize))
+func (d *Database) handleMsgSizeQuery(context.Context, compute.Query) Reply {
+	return makeValueReply(ValueType(d.maxMessageSize))
 }
 
-func (d *Database) handleWatchQuery(ctx context.Context, query compute.Query) string {
+func (d *Database) handleWatchQuery(ctx context.Context, query compute.Query) Reply {
 	arguments := query.Arguments()
 	key, err := makeBatchKey(arguments[0], arguments[1])
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
 	}
 
 	value, err := d.storageLayer.Watch(ctx, key)
 	if err != nil {
-		return makeErrorMsg(err)
+		return makeErrorReply(err)
 	}
 
-	return makeValueMsg(value)
+	return makeValueReply(value)
+}
+
+// handleSubscribeQuery subscribes the connection to threshold events of keys matching the pattern.
+// Events are pushed to the connection until it is closed.
+func (d *Database) handleSubscribeQuery(ctx context.Context, query compute.Query) Reply {
+	arguments := query.Arguments()
+	if strings.ToUpper(arguments[0]) != compute.SubscribeThresholdSubcommand {
+		return makeErrorReply(fmt.Errorf("%w: %s", errInvalidSubscription, arguments[0]))
+	}
+
+	pusher, ok := PusherFromContext(ctx)
+	if !ok {
+		return makeErrorReply(errPushNotSupported)
+	}
+
+	// the pattern is validated as a key
+	key, err := makeBatchKey(arguments[1], arguments[2])
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	limit, err := makeLimit(arguments[3])
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	if limit < minThresholdLimit {
+		return makeErrorReply(fmt.Errorf("%w: %d (must be at least %d)", errInvalidLimit, limit, minThresholdLimit))
+	}
+
+	events, cancel, err := d.storageLayer.SubscribeThreshold(ctx, key.Key, key.Window, limit)
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	release := pusher.Hold()
+
+	go func() {
+		defer release()
+		defer cancel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-events:
+				if err := pusher.Push([]byte(makeThresholdMsg(event))); err != nil {
+					d.logger.Warn().Err(err).Msg("failed to push threshold event")
+
+					return
+				}
+			}
+		}
+	}()
+
+	return makeBoolReply(true)
 }
 
 func makeBatchKey(key, batchSizeStr string) (BatchKey, error) {
@@ -186,19 +638,14 @@ func makeBatchKey(key, batchSizeStr string) (BatchKey, error) {
 		return BatchKey{}, errKeyTooLong
 	}
 
-	// Validate batch size
-	batchSize, err := strconv.ParseUint(batchSizeStr, 10, 64)
+	window, err := ParseWindow(batchSizeStr)
 	if err != nil {
-		return BatchKey{}, errBatchSizeNotNumber
-	}
-
-	if batchSize < minBatchSize || batchSize > maxBatchSize {
-		return BatchKey{}, fmt.Errorf("%w: %d (must be between %d and %d)", errInvalidBatchSize, batchSize, minBatchSize, maxBatchSize)
+		return BatchKey{}, err
 	}
 
 	return BatchKey{
-		BatchSize:    uint32(batchSize),
-		BatchSizeStr: batchSizeStr,
+		Window:       window,
+		BatchSizeStr: window.String(),
 		Key:          key,
 	}, nil
 }
@@ -222,43 +669,319 @@ func makeBatchKeys(args []string) ([]BatchKey, error) {
 	return res, nil
 }
 
-func makeErrorMsg(err error) string {
-	return "err|" + err.Error()
+func makeCappedBatchKeys(key string, cappingLimits []string, mode WindowMode) ([]BatchKey, []ValueType, error) {
+	keys := make([]BatchKey, 0, len(cappingLimits))
+	limits := make([]ValueType, 0, len(cappingLimits))
+	seen := make(map[Window]struct{}, len(cappingLimits))
+
+	for _, cappingLimit := range cappingLimits {
+		batchSizeStr, limitStr, found := strings.Cut(cappingLimit, ":")
+		if !found {
+			return nil, nil, errInvalidCappingLimit
+		}
+
+		batchKey, err := makeBatchKey(key, batchSizeStr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := seen[batchKey.Window]; ok {
+			return nil, nil, fmt.Errorf("%w: %s", errDuplicateCapping, batchKey.Window)
+		}
+		seen[batchKey.Window] = struct{}{}
+
+		limit, err := makeLimit(limitStr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		batchKey.Mode = mode
+		keys = append(keys, batchKey)
+		limits = append(limits, limit)
+	}
+
+	return keys, limits, nil
 }
 
-func makeValueMsg(v ValueType) string {
-	return "ok|" + strconv.FormatUint(uint64(v), 10)
+// makePolicy validates POLICY SET arguments.
+func makePolicy(name, windowStr, limitStr string) (Policy, error) {
+	if len(name) == 0 {
+		return Policy{}, errKeyEmpty
+	}
+	if len(name) > maxKeyLength {
+		return Policy{}, errKeyTooLong
+	}
+
+	window, err := ParseWindow(windowStr)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	limit, err := makeLimit(limitStr)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	return Policy{
+		Name:   name,
+		Window: window,
+		Limit:  limit,
+	}, nil
+}
+
+// writeOptions are optional arguments of write queries: [mode] [AT <timestamp>] in any order.
+type writeOptions struct {
+	mode  WindowMode
+	at    TxTime
+	hasAt bool
 }
 
-func makeBoolMsg(v bool) string {
-	var str string
-	if v {
-		str = "1"
-	} else {
-		str = "0"
+func makeWriteOptions(args []string, idx int) (writeOptions, error) {
+	var opts writeOptions
+
+	for i := idx; i < len(args); i++ {
+		if strings.EqualFold(args[i], atOption) {
+			if opts.hasAt || i+1 >= len(args) {
+				return writeOptions{}, errInvalidArgumentsCount
+			}
+
+			at, err := strconv.ParseUint(args[i+1], 10, 32)
+			if err != nil {
+				return writeOptions{}, fmt.Errorf("%w: %s", errTimestampNotNumber, args[i+1])
+			}
+
+			opts.at = TxTime(at)
+			opts.hasAt = true
+			i++
+
+			continue
+		}
+
+		if opts.mode != WindowModeDefault {
+			return writeOptions{}, errInvalidArgumentsCount
+		}
+
+		mode, err := makeWindowMode(args, i)
+		if err != nil {
+			return writeOptions{}, err
+		}
+
+		opts.mode = mode
 	}
 
-	return "ok|" + str
+	return opts, nil
 }
 
-func makeBoolsMsg(arr []bool) string {
-	var buff strings.Builder
-	buff.Grow(len(arr)*2 + 3)
+// apply puts the explicit time of the event into the context.
+// The time must fall into the current or the previous window of every key,
+// as older windows are not retained. Timestamps slightly ahead of the server clock are set to now.
+func (o writeOptions) apply(ctx context.Context, windows ...Window) (context.Context, error) {
+	if !o.hasAt {
+		return ctx, nil
+	}
+
+	now := time.Now().Unix()
+	at := int64(o.at)
 
-	buff.WriteString("ok")
-	buff.WriteByte('|')
+	if at > now+maxTimestampSkew {
+		return ctx, fmt.Errorf("%w: %d (now is %d)", errTimestampInFuture, at, now)
+	}
+	at = min(at, now)
 
-	for i, v := range arr {
-		if v {
-			buff.WriteString("1")
-		} else {
-			buff.WriteString("0")
+	for _, window := range windows {
+		if retainedFrom := window.Start(window.Start(now) - 1); at < retainedFrom {
+			return ctx, fmt.Errorf("%w: %d (must not be before %d for window %s)",
+				errStaleTimestamp, at, retainedFrom, window)
 		}
+	}
+
+	return ContextWithTxTime(ctx, TxTime(at)), nil
+}
+
+// makeWindowMode parses an optional window mode argument placed at idx.
+func makeWindowMode(args []string, idx int) (WindowMode, error) {
+	if len(args) <= idx {
+		return WindowModeDefault, nil
+	}
+
+	mode, err := ParseWindowMode(args[idx])
+	if err != nil {
+		return WindowModeDefault, fmt.Errorf("%w: %s (must be %s or %s)",
+			err, args[idx], WindowModeFixedName, WindowModeSlidingName)
+	}
+
+	return mode, nil
+}
+
+func makeLimit(limitStr string) (ValueType, error) {
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil {
+		return 0, errLimitNotNumber
+	}
+
+	if limit < minLimit || limit > maxLimit {
+		return 0, fmt.Errorf("%w: %d (must be between %d and %d)", errInvalidLimit, limit, minLimit, maxLimit)
+	}
+
+	return ValueType(limit), nil
+}
 
-		if i < len(arr)-1 {
-			buff.WriteString(";")
+func makeDelta(deltaStr string) (ValueType, error) {
+	delta, err := strconv.ParseInt(deltaStr, 10, 64)
+	if err != nil {
+		return 0, errDeltaNotNumber
+	}
+
+	if delta < minDelta || delta > maxDelta {
+		return 0, fmt.Errorf("%w: %d (must be between %d and %d)", errInvalidDelta, delta, minDelta, maxDelta)
+	}
+
+	return ValueType(delta), nil
+}
+
+// makeThrottleKey parses THROTTLE arguments: key, rate, period, burst and optional quantity.
+func makeThrottleKey(args []string) (ThrottleKey, error) {
+	key := ThrottleKey{
+		Key:       args[0],
+		PeriodStr: args[2],
+		Quantity:  1,
+	}
+
+	if len(key.Key) == 0 {
+		return ThrottleKey{}, errKeyEmpty
+	}
+	if len(key.Key) > maxKeyLength {
+		return ThrottleKey{}, errKeyTooLong
+	}
+
+	rate, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return ThrottleKey{}, errRateNotNumber
+	}
+	if rate < minRate {
+		return ThrottleKey{}, fmt.Errorf("%w: %d (must be at least %d)", errInvalidRate, rate, minRate)
+	}
+	key.Rate = rate
+
+	period, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		return ThrottleKey{}, errPeriodNotNumber
+	}
+	if period < minPeriod || period > maxPeriod {
+		return ThrottleKey{}, fmt.Errorf("%w: %d (must be between %d and %d)", errInvalidPeriod, period, minPeriod, maxPeriod)
+	}
+	key.Period = uint32(period)
+
+	burst, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return ThrottleKey{}, errBurstNotNumber
+	}
+	if burst < minBurst {
+		return ThrottleKey{}, fmt.Errorf("%w: %d (must be at least %d)", errInvalidBurst, burst, minBurst)
+	}
+	key.Burst = burst
+
+	if len(args) > 4 {
+		quantity, err := strconv.ParseInt(args[4], 10, 64)
+		if err != nil {
+			return ThrottleKey{}, errQuantityNotNumber
+		}
+		if quantity < minQuantity {
+			return ThrottleKey{}, fmt.Errorf("%w: %d (must be at least %d)", errInvalidQuantity, quantity, minQuantity)
 		}
+		key.Quantity = quantity
+	}
+
+	if err := key.Validate(); err != nil {
+		return ThrottleKey{}, err
+	}
+
+	return key, nil
+}
+
+func makeErrorReply(err error) Reply {
+	return Reply{Err: err}
+}
+
+func makeValueReply(v ValueType) Reply {
+	return Reply{Values: []ReplyValue{IntReplyValue(int64(v))}}
+}
+
+func makeBoolReply(v bool) Reply {
+	return Reply{Values: []ReplyValue{BoolReplyValue(v)}}
+}
+
+func makeCapReply(allowed bool, v ValueType) Reply {
+	return Reply{Values: []ReplyValue{BoolReplyValue(allowed), IntReplyValue(int64(v))}}
+}
+
+func makeCapsReply(allowed bool, arr []ValueType) Reply {
+	values := make([]ReplyValue, 0, len(arr)+1)
+	values = append(values, BoolReplyValue(allowed))
+	for _, v := range arr {
+		values = append(values, IntReplyValue(int64(v)))
+	}
+
+	return Reply{Values: values}
+}
+
+// makeThrottleReply formats a throttle result as allowed;limit;remaining;retry_after_ms;reset_after_ms.
+func makeThrottleReply(res ThrottleResult) Reply {
+	return Reply{Values: []ReplyValue{
+		BoolReplyValue(res.Allowed),
+		IntReplyValue(res.Limit),
+		IntReplyValue(res.Remaining),
+		IntReplyValue(ceilMilliseconds(res.RetryAfter)),
+		IntReplyValue(ceilMilliseconds(res.ResetAfter)),
+	}}
+}
+
+// ceilMilliseconds rounds up, so a client waiting the returned time is not throttled again.
+func ceilMilliseconds(d time.Duration) int64 {
+	return int64((d + time.Millisecond - 1) / time.Millisecond)
+}
+
+// makeHistoryReply formats history entries as start:value;start:value...
+func makeHistoryReply(entries []HistoryEntry) Reply {
+	values := make([]ReplyValue, 0, len(entries))
+	for _, entry := range entries {
+		values = append(values, TupleReplyValue(IntReplyValue(entry.Start), IntReplyValue(int64(entry.Value))))
+	}
+
+	return Reply{Values: values, Array: true}
+}
+
+// makePolicyReply formats a policy as window;limit[;mode].
+func makePolicyReply(policy Policy) Reply {
+	values := []ReplyValue{StringReplyValue(policy.Window.String()), IntReplyValue(int64(policy.Limit))}
+	if policy.Mode != WindowModeDefault {
+		values = append(values, StringReplyValue(policy.Mode.String()))
+	}
+
+	return Reply{Values: values}
+}
+
+// makeThresholdMsg formats a pushed threshold event as threshold;key;window;limit;value.
+// Pushed messages end with a newline, so a client can split several of them read at once.
+func makeThresholdMsg(event ThresholdEvent) string {
+	return "push|threshold;" + event.Key + ";" + event.Window.String() + ";" +
+		strconv.FormatInt(int64(event.Limit), 10) + ";" + strconv.FormatInt(int64(event.Value), 10) + "\n"
+}
+
+func makeValuesReply(arr []ValueType) Reply {
+	values := make([]ReplyValue, 0, len(arr))
+	for _, v := range arr {
+		values = append(values, IntReplyValue(int64(v)))
+	}
+
+	return Reply{Values: values, Array: true}
+}
+
+func makeBoolsReply(arr []bool) Reply {
+	values := make([]ReplyValue, 0, len(arr))
+	for _, v := range arr {
+		values = append(values, BoolReplyValue(v))
 	}
 
-	return buff.String()
+	return Reply{Values: values, Array: true}
 }
@@ -1,5 +1,91 @@
 package database
 
-import "errors"
+import (
+	"context"
+	"errors"
 
-var ErrDumpReadSessionClosed = errors.New("dump read session is closed")
+	"fq/internal/database/compute"
+)
+
+// ErrorCode is a stable class of errors of queries, clients decide by it whether to retry a query.
+type ErrorCode string
+
+const (
+	// ErrorCodeArgs is an invalid command or invalid arguments, the query fails on retry too
+	ErrorCodeArgs ErrorCode = "E_ARGS"
+	// ErrorCodeNotFound is a missing object, e.g. a policy
+	ErrorCodeNotFound ErrorCode = "E_NOT_FOUND"
+	// ErrorCodeReadOnly is a write to an object or a node which can't be changed
+	ErrorCodeReadOnly ErrorCode = "E_READONLY"
+	// ErrorCodeOverflow is a counter which can't be incremented by the delta
+	ErrorCodeOverflow ErrorCode = "E_OVERFLOW"
+	// ErrorCodeDurability is a write which didn't reach the WAL, it isn't applied
+	ErrorCodeDurability ErrorCode = "E_DURABILITY"
+	// ErrorCodeUnconfirmed is a write of a pipeline which is applied in memory but didn't reach the WAL
+	ErrorCodeUnconfirmed ErrorCode = "E_UNCONFIRMED"
+	// ErrorCodeTooLarge is a message exceeding the max message size
+	ErrorCodeTooLarge ErrorCode = "E_TOO_LARGE"
+	// ErrorCodeUnsupported is a query which the connection doesn't support, e.g. SUBSCRIBE without server push
+	ErrorCodeUnsupported ErrorCode = "E_UNSUPPORTED"
+	// ErrorCodeUnavailable is a query which needs an unreachable node, e.g. a write forwarded to the master
+	ErrorCodeUnavailable ErrorCode = "E_UNAVAILABLE"
+	// ErrorCodeTimeout is a query interrupted by its deadline or by the closed connection
+	ErrorCodeTimeout ErrorCode = "E_TIMEOUT"
+	// ErrorCodeInternal is any other error
+	ErrorCodeInternal ErrorCode = "E_INTERNAL"
+)
+
+// Error is an error of a query with a stable code. Errors are compared by identity,
+// so errors made by NewError are used as sentinels and wrapped with details.
+type Error struct {
+	code    ErrorCode
+	message string
+}
+
+func NewError(code ErrorCode, message string) error {
+	return &Error{code: code, message: message}
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+func (e *Error) Code() ErrorCode {
+	return e.code
+}
+
+var (
+	ErrDumpReadSessionClosed = NewError(ErrorCodeInternal, "dump read session is closed")
+	ErrValueOverflow         = NewError(ErrorCodeOverflow, "value overflow")
+	ErrMessageTooLarge       = NewError(ErrorCodeTooLarge, "message size exceeds maximum")
+	// ErrNotDurable wraps errors of writing to the WAL
+	ErrNotDurable = NewError(ErrorCodeDurability, "write is not durable")
+	// ErrAppliedNotDurable wraps errors of writing to the WAL of writes which are already applied, e.g. of pipelines
+	ErrAppliedNotDurable = NewError(ErrorCodeUnconfirmed, "write is applied but not durable")
+	// ErrPipelineNotFramed is a message with several queries of a connection without frames,
+	// whose responses can't be told from pushed messages
+	ErrPipelineNotFramed = NewError(ErrorCodeUnsupported, "pipeline needs the framed protocol")
+	// ErrReadOnlyReplica is a write to a slave, which only applies changes of the master
+	ErrReadOnlyReplica = NewError(ErrorCodeReadOnly, "replica is read-only")
+	// ErrReplicationDisabled is a change of the replication role of a node without replication
+	ErrReplicationDisabled = NewError(ErrorCodeUnsupported, "replication is not configured")
+	// ErrMasterUnavailable wraps errors of forwarding writes of a slave to the master
+	ErrMasterUnavailable = NewError(ErrorCodeUnavailable, "master is unavailable")
+)
+
+// ErrorCodeOf returns the code of an error of a query, errors without a code are internal.
+func ErrorCodeOf(err error) ErrorCode {
+	var dbErr *Error
+	var queryErr *compute.QueryError
+
+	switch {
+	case errors.As(err, &dbErr):
+		return dbErr.Code()
+	case errors.As(err, &queryErr):
+		return ErrorCodeArgs
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeTimeout
+	default:
+		return ErrorCodeInternal
+	}
+}
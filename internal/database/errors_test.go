@@ -0,0 +1,41 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+	"fq/internal/database/compute"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		code database.ErrorCode
+	}{
+		"query error":       {err: compute.ErrInvalidCommand, code: database.ErrorCodeArgs},
+		"wrapped argument":  {err: fmt.Errorf("%w: 1y", database.ErrInvalidWindow), code: database.ErrorCodeArgs},
+		"read only policy":  {err: database.ErrPolicyReadOnly, code: database.ErrorCodeReadOnly},
+		"missing policy":    {err: database.ErrPolicyNotFound, code: database.ErrorCodeNotFound},
+		"overflow":          {err: database.ErrValueOverflow, code: database.ErrorCodeOverflow},
+		"too large message": {err: database.ErrMessageTooLarge, code: database.ErrorCodeTooLarge},
+		"not durable":       {err: fmt.Errorf("%w: %w", database.ErrNotDurable, errors.New("disk is full")), code: database.ErrorCodeDurability},
+		"applied not durable": {
+			err:  fmt.Errorf("%w: %w", database.ErrAppliedNotDurable, errors.New("disk is full")),
+			code: database.ErrorCodeUnconfirmed,
+		},
+		"deadline": {err: context.DeadlineExceeded, code: database.ErrorCodeTimeout},
+		"unknown":  {err: errors.New("unknown"), code: database.ErrorCodeInternal},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.code, database.ErrorCodeOf(test.err))
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// forwardedPrefix starts a write forwarded by a slave, '|' is never a symbol of a query.
+// A forwarded write carries the command and its arguments as a JSON array and is answered
+// with a JSON reply, which keeps the types of values, tuples and lists, so the reply of the master
+// is returned by the slave as is.
+const forwardedPrefix = "fwd|"
+
+var (
+	errInvalidForwardedMessage = errors.New("invalid forwarded message")
+	errForwardNotFramed        = NewError(ErrorCodeUnsupported, "forwarded write needs the framed protocol")
+)
+
+// forwardedReply is the encoding of a reply to a forwarded write.
+type forwardedReply struct {
+	Code    ErrorCode    `json:"code,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Values  []ReplyValue `json:"values,omitempty"`
+	Array   bool         `json:"array,omitempty"`
+}
+
+// HandleMessage handles a message of the native protocol: a write forwarded by a slave or a pipeline of queries.
+// Forwarded writes are only accepted from framed connections, like pipelines.
+func (d *Database) HandleMessage(ctx context.Context, message string) string {
+	payload, forwarded := strings.CutPrefix(message, forwardedPrefix)
+	if !forwarded {
+		return d.HandlePipeline(ctx, message)
+	}
+
+	if !FramingFromContext(ctx) {
+		return encodeForwardedReply(makeErrorReply(errForwardNotFramed))
+	}
+
+	var args []string
+	if err := json.Unmarshal([]byte(payload), &args); err != nil {
+		return encodeForwardedReply(makeErrorReply(fmt.Errorf("%w: %w", errInvalidForwardedMessage, err)))
+	}
+
+	return encodeForwardedReply(d.HandleCommand(ctx, args))
+}
+
+// forward sends a write command to the master and returns its reply.
+func (d *Database) forward(ctx context.Context, name string, arguments []string) Reply {
+	request, err := json.Marshal(append([]string{name}, arguments...))
+	if err != nil {
+		return makeErrorReply(err)
+	}
+
+	response, err := d.forwarder.Forward(ctx, forwardedPrefix+string(request))
+	if err != nil {
+		return makeErrorReply(fmt.Errorf("%w: %w", ErrMasterUnavailable, err))
+	}
+
+	reply, err := decodeForwardedReply(response)
+	if err != nil {
+		return makeErrorReply(fmt.Errorf("%w: %w", ErrMasterUnavailable, err))
+	}
+
+	return reply
+}
+
+func encodeForwardedReply(reply Reply) string {
+	encoded := forwardedReply{Values: reply.Values, Array: reply.Array}
+	if reply.Err != nil {
+		encoded = forwardedReply{Code: ErrorCodeOf(reply.Err), Message: reply.Err.Error()}
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return makeErrorReply(err).String()
+	}
+
+	return string(data)
+}
+
+// decodeForwardedReply decodes the reply of the master, errors keep their codes.
+func decodeForwardedReply(response string) (Reply, error) {
+	var decoded forwardedReply
+	if err := json.Unmarshal([]byte(response), &decoded); err != nil {
+		return Reply{}, fmt.Errorf("%w: %w", errInvalidForwardedMessage, err)
+	}
+
+	if decoded.Code != "" {
+		return Reply{Err: NewError(decoded.Code, decoded.Message)}, nil
+	}
+
+	return Reply{Values: decoded.Values, Array: decoded.Array}, nil
+}
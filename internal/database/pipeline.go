@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"fq/internal/tools"
+)
+
+// pipelineSeparator separates queries of a pipeline and their responses.
+const pipelineSeparator = "\n"
+
+// CommitGroup collects WAL commits of a query, so queries of a pipeline wait for the WAL together
+// and their records share a WAL batch.
+type CommitGroup struct {
+	mutex   sync.Mutex
+	futures []tools.FutureError
+}
+
+func (g *CommitGroup) Add(future tools.FutureError) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.futures = append(g.futures, future)
+}
+
+// Wait waits for all commits of the group and returns the first error wrapped by ErrAppliedNotDurable:
+// writes of the group are applied before they are confirmed by the WAL.
+func (g *CommitGroup) Wait() error {
+	g.mutex.Lock()
+	futures := g.futures
+	g.futures = nil
+	g.mutex.Unlock()
+
+	var firstErr error
+	for _, future := range futures {
+		if err := future.Get(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%w: %w", ErrAppliedNotDurable, err)
+		}
+	}
+
+	return firstErr
+}
+
+// HandlePipeline handles newline-separated queries of a message and returns their responses
+// in the same order, separated by newlines. A message with a single query is handled by HandleQuery,
+// several queries need a framed connection, so their responses can't be mixed up with pushed messages.
+// With sync commit the responses are returned after all writes of the pipeline reach the WAL.
+// Unlike single queries, writes of a pipeline are applied before they reach the WAL, so they are
+// at least applied: a write which failed to reach it gets the E_UNCONFIRMED response of ErrAppliedNotDurable.
+func (d *Database) HandlePipeline(ctx context.Context, message string) string {
+	queries := splitPipeline(message)
+	if len(queries) <= 1 {
+		return d.HandleQuery(ctx, message)
+	}
+
+	if !FramingFromContext(ctx) {
+		return makeErrorReply(ErrPipelineNotFramed).String()
+	}
+
+	responses := make([]string, len(queries))
+	groups := make([]CommitGroup, len(queries))
+	for i, query := range queries {
+		responses[i] = d.HandleQuery(ContextWithCommitGroup(ctx, &groups[i]), query)
+	}
+
+	for i := range groups {
+		if err := groups[i].Wait(); err != nil {
+			responses[i] = makeErrorReply(err).String()
+		}
+	}
+
+	return strings.Join(responses, pipelineSeparator)
+}
+
+// splitPipeline returns non-empty queries of a message.
+func splitPipeline(message string) []string {
+	lines := strings.Split(message, pipelineSeparator)
+	queries := lines[:0]
+	for _, line := range lines {
+		if query := strings.TrimSpace(line); query != "" {
+			queries = append(queries, query)
+		}
+	}
+
+	return queries
+}
@@ -0,0 +1,87 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+	"fq/internal/database/compute"
+	"fq/internal/database/storage"
+	inmemory "fq/internal/database/storage/engine/in-memory"
+	"fq/internal/tools"
+)
+
+func TestDatabase_HandlePipeline(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := inmemory.NewEngine(inmemory.HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	strg, err := storage.NewStorage(engine, nil, nil, nil, &logger, time.Minute, time.Minute, false)
+	require.NoError(t, err)
+
+	commands := compute.NewRegistry()
+	computeLayer := compute.NewCompute(compute.NewParser(&logger), compute.NewAnalyzer(commands, &logger), &logger)
+	db := database.NewDatabase(computeLayer, strg, commands, &logger, 4096)
+
+	tests := map[string]struct {
+		message  string
+		unframed bool
+		response string
+	}{
+		"single query": {
+			message:  "INCR single 60\n",
+			response: "ok|1",
+		},
+		"pipeline": {
+			message:  "INCR key 60\nINCR key 60\nGET key 60\n",
+			response: "ok|1\nok|2\nok|2",
+		},
+		"pipeline with invalid query": {
+			message:  "INCR other 60\nUNKNOWN\n\nGET other 60",
+			response: "ok|1\nerr|E_ARGS|invalid command\nok|1",
+		},
+		"single query of unframed connection": {
+			message:  "INCR unframed 60\n",
+			unframed: true,
+			response: "ok|1",
+		},
+		"pipeline of unframed connection": {
+			message:  "INCR unframed 60\nGET unframed 60",
+			unframed: true,
+			response: "err|E_UNSUPPORTED|pipeline needs the framed protocol",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if !test.unframed {
+				ctx = database.ContextWithFraming(ctx)
+			}
+
+			require.Equal(t, test.response, db.HandlePipeline(ctx, test.message))
+		})
+	}
+}
+
+func TestCommitGroup_Wait(t *testing.T) {
+	errWrite := errors.New("write failed")
+
+	var group database.CommitGroup
+	for _, err := range []error{nil, errWrite, nil} {
+		result := make(chan error, 1)
+		result <- err
+		group.Add(tools.NewFuture[error](result))
+	}
+
+	err := group.Wait()
+	require.ErrorIs(t, err, errWrite)
+	require.ErrorIs(t, err, database.ErrAppliedNotDurable)
+	require.NoError(t, group.Wait())
+}
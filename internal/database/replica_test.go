@@ -0,0 +1,279 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+	"fq/internal/database/compute"
+	"fq/internal/database/storage"
+	inmemory "fq/internal/database/storage/engine/in-memory"
+	"fq/internal/database/storage/wal"
+)
+
+type slaveReplica struct{}
+
+func (slaveReplica) Start(context.Context) {}
+func (slaveReplica) IsMaster() bool        { return false }
+func (slaveReplica) Shutdown()             {}
+
+type forwarderFunc func(ctx context.Context, query string) (string, error)
+
+func (f forwarderFunc) Forward(ctx context.Context, query string) (string, error) {
+	return f(ctx, query)
+}
+
+func newSlaveDatabase(t *testing.T) *database.Database {
+	t.Helper()
+
+	logger := zerolog.Nop()
+	engine, err := inmemory.NewEngine(inmemory.HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	strg, err := storage.NewStorage(engine, nil, nil, slaveReplica{}, &logger, time.Minute, time.Minute, false)
+	require.NoError(t, err)
+	require.True(t, strg.IsReadOnly())
+
+	commands := compute.NewRegistry()
+	computeLayer := compute.NewCompute(compute.NewParser(&logger), compute.NewAnalyzer(commands, &logger), &logger)
+
+	return database.NewDatabase(computeLayer, strg, commands, &logger, 4096)
+}
+
+func TestDatabase_ReadOnlyReplica(t *testing.T) {
+	db := newSlaveDatabase(t)
+
+	tests := map[string]struct {
+		query    string
+		response string
+	}{
+		"incr": {
+			query:    "INCR key 60",
+			response: "err|E_READONLY|replica is read-only",
+		},
+		"mdel": {
+			query:    "MDEL key 60 other 60",
+			response: "err|E_READONLY|replica is read-only",
+		},
+		"policy set": {
+			query:    "POLICY SET campaign 86400 5",
+			response: "err|E_READONLY|replica is read-only",
+		},
+		"get": {
+			query:    "GET key 60",
+			response: "ok|0",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.response, db.HandleQuery(context.Background(), test.query))
+		})
+	}
+}
+
+func newMasterDatabase(t *testing.T) *database.Database {
+	t.Helper()
+
+	logger := zerolog.Nop()
+	engine, err := inmemory.NewEngine(inmemory.HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	strg, err := storage.NewStorage(engine, nil, nil, nil, &logger, time.Minute, time.Minute, false)
+	require.NoError(t, err)
+
+	commands := compute.NewRegistry()
+	computeLayer := compute.NewCompute(compute.NewParser(&logger), compute.NewAnalyzer(commands, &logger), &logger)
+
+	return database.NewDatabase(computeLayer, strg, commands, &logger, 4096)
+}
+
+func TestDatabase_ForwardWrites(t *testing.T) {
+	db := newSlaveDatabase(t)
+	master := newMasterDatabase(t)
+
+	var forwarded []string
+	db.SetForwarder(forwarderFunc(func(ctx context.Context, query string) (string, error) {
+		forwarded = append(forwarded, query)
+		if strings.Contains(query, "DEL") {
+			return "", errors.New("connection refused")
+		}
+
+		return master.HandleMessage(database.ContextWithFraming(ctx), query), nil
+	}))
+
+	tests := map[string]struct {
+		query    string
+		response string
+	}{
+		"incr": {
+			query:    "INCR key 60",
+			response: "ok|1",
+		},
+		"error of the master": {
+			query:    "CAPINCR key 60 x",
+			response: "err|E_ARGS|limit is not a number",
+		},
+		"unavailable master": {
+			query:    "DEL key 60",
+			response: "err|E_UNAVAILABLE|master is unavailable: connection refused",
+		},
+		"read is not forwarded": {
+			query:    "GET key 60",
+			response: "ok|0",
+		},
+		"read subcommand is not forwarded": {
+			query:    "POLICY GET campaign",
+			response: "err|E_NOT_FOUND|policy not found",
+		},
+		"write subcommand is forwarded": {
+			query:    "POLICY SET campaign 86400 5",
+			response: "ok|1",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.response, db.HandleQuery(context.Background(), test.query))
+		})
+	}
+
+	require.ElementsMatch(t, []string{
+		`fwd|["INCR","key","60"]`,
+		`fwd|["CAPINCR","key","60","x"]`,
+		`fwd|["DEL","key","60"]`,
+		`fwd|["POLICY","SET","campaign","86400","5"]`,
+	}, forwarded)
+
+	// forwarded writes are framed, like pipelines
+	require.Equal(t,
+		`{"code":"E_UNSUPPORTED","message":"forwarded write needs the framed protocol"}`,
+		master.HandleMessage(context.Background(), `fwd|["INCR","key","60"]`),
+	)
+
+	// replies of the master keep lists of a single value and tuples, e.g. of keys with ':'
+	for _, args := range [][]string{
+		{"MINCR", "<key>", "60"},
+		{"MCAPINCR", "<key>", "60:5", "3600:10"},
+	} {
+		expected := master.HandleCommand(context.Background(), withKey(args, "user:1"))
+		reply := db.HandleCommand(context.Background(), withKey(args, "user:2"))
+		require.NoError(t, reply.Err)
+		require.Equal(t, expected, reply)
+	}
+
+	reply := db.HandleCommand(context.Background(), []string{"MINCR", "user:3", "60"})
+	require.True(t, reply.Array)
+	require.Equal(t, []database.ReplyValue{database.IntReplyValue(1)}, reply.Values)
+
+	reply = db.HandleCommand(context.Background(), []string{"INCR", "key with spaces", "60"})
+	require.Equal(t, database.ErrorCodeArgs, database.ErrorCodeOf(reply.Err))
+}
+
+// withKey replaces the key of the arguments of a command.
+func withKey(args []string, key string) []string {
+	return append([]string{args[0], key}, args[2:]...)
+}
+
+type switchableReplica struct {
+	master        bool
+	lastLSN       uint64
+	masterAddress string
+	err           error
+}
+
+func (r *switchableReplica) Start(context.Context) {}
+func (r *switchableReplica) IsMaster() bool        { return r.master }
+func (r *switchableReplica) Shutdown()             {}
+
+func (r *switchableReplica) Promote() (uint64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	r.master = true
+
+	return r.lastLSN, nil
+}
+
+func (r *switchableReplica) ReplicaOf(masterAddress string, reset func()) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	reset()
+
+	r.master = false
+	r.masterAddress = masterAddress
+	r.lastLSN = 0
+
+	return nil
+}
+
+func TestDatabase_ReplicaRoles(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := inmemory.NewEngine(inmemory.HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	directory := t.TempDir()
+	writeAheadLog := wal.NewWAL(
+		wal.NewFSWriter(directory, 1<<20, &logger),
+		wal.NewFSReader(directory, &logger),
+		nil,
+		time.Millisecond,
+		100,
+		directory,
+		&logger,
+	)
+
+	replica := &switchableReplica{lastLSN: 7}
+	strg, err := storage.NewStorage(engine, writeAheadLog, nil, replica, &logger, time.Minute, time.Minute, false)
+	require.NoError(t, err)
+
+	commands := compute.NewRegistry()
+	computeLayer := compute.NewCompute(compute.NewParser(&logger), compute.NewAnalyzer(commands, &logger), &logger)
+	db := database.NewDatabase(computeLayer, strg, commands, &logger, 4096)
+
+	require.Equal(t, "err|E_READONLY|replica is read-only", db.HandleQuery(context.Background(), "INCR key 60"))
+
+	require.Equal(t, "ok|7", db.HandleQuery(context.Background(), "REPLICA PROMOTE"))
+	require.True(t, replica.master)
+	require.Equal(t, "ok|1", db.HandleQuery(context.Background(), "INCR key 60"))
+	require.Equal(t, "ok|8", db.HandleQuery(context.Background(), "replica promote"))
+
+	// the state of the node is reset, it's resynchronized from the new master
+	require.Equal(t, "ok|", db.HandleQuery(context.Background(), "REPLICA OF 127.0.0.1:1945"))
+	require.False(t, replica.master)
+	require.Equal(t, "127.0.0.1:1945", replica.masterAddress)
+	require.Equal(t, "ok|0", db.HandleQuery(context.Background(), "GET key 60"))
+	require.Equal(t, "err|E_READONLY|replica is read-only", db.HandleQuery(context.Background(), "INCR key 60"))
+
+	// transactions continue from the new master
+	replica.lastLSN = 3
+	require.Equal(t, "ok|3", db.HandleQuery(context.Background(), "REPLICA PROMOTE"))
+	require.Equal(t, "ok|1", db.HandleQuery(context.Background(), "INCR key 60"))
+
+	// a master which can't be reached doesn't change the role and the state
+	replica.err = database.ErrMasterUnavailable
+	require.Equal(t, "err|E_UNAVAILABLE|master is unavailable", db.HandleQuery(context.Background(), "REPLICA OF 127.0.0.1:1946"))
+	require.Equal(t, "127.0.0.1:1945", replica.masterAddress)
+	require.Equal(t, "ok|1", db.HandleQuery(context.Background(), "GET key 60"))
+
+	require.Equal(t, "err|E_ARGS|invalid replica subcommand: STOP", db.HandleQuery(context.Background(), "REPLICA STOP"))
+	require.Equal(t, "err|E_ARGS|invalid replica subcommand: OF", db.HandleQuery(context.Background(), "REPLICA OF"))
+}
+
+func TestDatabase_ReplicaWithoutReplication(t *testing.T) {
+	db := newSlaveDatabase(t)
+
+	require.Equal(t, "err|E_UNSUPPORTED|replication is not configured", db.HandleQuery(context.Background(), "REPLICA PROMOTE"))
+	require.Equal(t, "err|E_UNSUPPORTED|replication is not configured", db.HandleQuery(context.Background(), "REPLICA OF :1945"))
+}
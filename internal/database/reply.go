@@ -0,0 +1,95 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ReplyValueKind is a kind of a value of a reply.
+type ReplyValueKind uint8
+
+const (
+	ReplyValueInt ReplyValueKind = iota
+	ReplyValueString
+	// ReplyValueTuple is a group of values, e.g. the start and the value of a closed window
+	ReplyValueTuple
+)
+
+// ReplyValue is a value of a reply.
+type ReplyValue struct {
+	Kind  ReplyValueKind `json:"kind"`
+	Int   int64          `json:"int,omitempty"`
+	Str   string         `json:"str,omitempty"`
+	Tuple []ReplyValue   `json:"tuple,omitempty"`
+}
+
+func IntReplyValue(v int64) ReplyValue {
+	return ReplyValue{Kind: ReplyValueInt, Int: v}
+}
+
+// BoolReplyValue makes 1 for true and 0 for false.
+func BoolReplyValue(v bool) ReplyValue {
+	if v {
+		return IntReplyValue(1)
+	}
+
+	return IntReplyValue(0)
+}
+
+func StringReplyValue(v string) ReplyValue {
+	return ReplyValue{Kind: ReplyValueString, Str: v}
+}
+
+func TupleReplyValue(values ...ReplyValue) ReplyValue {
+	return ReplyValue{Kind: ReplyValueTuple, Tuple: values}
+}
+
+// Reply is a result of a query. The native protocol encodes it as ok|<values> or err|<code>|<message>,
+// other protocols (RESP) keep the types of values.
+type Reply struct {
+	Err    error
+	Values []ReplyValue
+	// Array marks a list of values of the same meaning (e.g. MGET values), which is a list
+	// even with a single value. Other replies with several values are fixed tuples (e.g. CAPINCR).
+	Array bool
+}
+
+// String encodes the reply for the native protocol: values are separated by ';'
+// and values of a tuple by ':'.
+func (r Reply) String() string {
+	if r.Err != nil {
+		return "err|" + string(ErrorCodeOf(r.Err)) + "|" + r.Err.Error()
+	}
+
+	var buff strings.Builder
+	buff.Grow(len(r.Values)*4 + 3)
+
+	buff.WriteString("ok|")
+
+	for i, v := range r.Values {
+		if i > 0 {
+			buff.WriteByte(';')
+		}
+
+		v.writeTo(&buff)
+	}
+
+	return buff.String()
+}
+
+func (v ReplyValue) writeTo(buff *strings.Builder) {
+	switch v.Kind {
+	case ReplyValueInt:
+		buff.WriteString(strconv.FormatInt(v.Int, 10))
+	case ReplyValueString:
+		buff.WriteString(v.Str)
+	case ReplyValueTuple:
+		for i, item := range v.Tuple {
+			if i > 0 {
+				buff.WriteByte(':')
+			}
+
+			item.writeTo(buff)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+)
+
+func TestReplyString(t *testing.T) {
+	tests := map[string]struct {
+		reply database.Reply
+		str   string
+	}{
+		"empty": {
+			str:   "ok|",
+			reply: database.Reply{},
+		},
+		"value": {
+			str:   "ok|5",
+			reply: database.Reply{Values: []database.ReplyValue{database.IntReplyValue(5)}},
+		},
+		"list of a single value": {
+			str: "ok|5",
+			reply: database.Reply{
+				Values: []database.ReplyValue{database.IntReplyValue(5)},
+				Array:  true,
+			},
+		},
+		"values": {
+			str: "ok|1;campaign",
+			reply: database.Reply{
+				Values: []database.ReplyValue{database.IntReplyValue(1), database.StringReplyValue("campaign")},
+				Array:  true,
+			},
+		},
+		"tuple": {
+			str: "ok|1760000400:2",
+			reply: database.Reply{Values: []database.ReplyValue{
+				database.TupleReplyValue(database.IntReplyValue(1760000400), database.IntReplyValue(2)),
+			}},
+		},
+		"error": {
+			str:   "err|E_ARGS|invalid arguments",
+			reply: database.Reply{Err: database.NewError(database.ErrorCodeArgs, "invalid arguments")},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.str, test.reply.String())
+		})
+	}
+}
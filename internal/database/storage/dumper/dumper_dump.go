@@ -36,6 +36,10 @@ func (d *Dumper) Dump(ctx context.Context, dumpTx database.Tx) error {
 
 	defer func() { _ = f.Close() }()
 
+	if err := writeDumpHeader(f); err != nil {
+		return err
+	}
+
 	dumpBatch := make([]database.DumpElem, 0, dumpBatchSize)
 
 	elemsC, errC := d.engine.Dump(ctx, dumpTx)
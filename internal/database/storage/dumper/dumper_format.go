@@ -0,0 +1,98 @@
+package dumper
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"fq/internal/database"
+)
+
+// Elements are gob-encoded, which matches fields by name, so V2 and V3 dumps are decoded into
+// database.DumpElem alike, fields missing in older dumps get zero values. The version is bumped
+// when older readers would misread the elements, they refuse dumps of newer versions:
+// e.g. calendar windows of V3 dumps are kept in Window, which V2 readers don't know.
+const (
+	// dumpFormatV1 is the legacy headerless format with 32-bit counter values
+	dumpFormatV1 byte = 1
+	// dumpFormatV2 is prefixed with a header and stores 64-bit counter values
+	dumpFormatV2 byte = 2
+	// dumpFormatV3 stores window specs of counters, e.g. calendar windows
+	dumpFormatV3 byte = 3
+
+	currentDumpFormat = dumpFormatV3
+)
+
+var dumpMagic = []byte("FQDUMP")
+
+type dumpElemV1 struct {
+	Key       string
+	BatchSize uint32
+	Value     int32
+	TxAt      database.TxTime
+	Tx        database.Tx
+}
+
+func writeDumpHeader(w io.Writer) error {
+	header := make([]byte, 0, len(dumpMagic)+1)
+	header = append(header, dumpMagic...)
+	header = append(header, currentDumpFormat)
+
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("write dump header: %w", err)
+	}
+
+	return nil
+}
+
+// readDumpHeader consumes the dump header from the buffer and returns the dump format version.
+// Dumps without a header are written by older versions and have the legacy format.
+func readDumpHeader(buff *bytes.Buffer) (byte, error) {
+	if !bytes.HasPrefix(buff.Bytes(), dumpMagic) {
+		return dumpFormatV1, nil
+	}
+
+	buff.Next(len(dumpMagic))
+	version, err := buff.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("read dump format version: %w", err)
+	}
+
+	if version > currentDumpFormat {
+		return 0, fmt.Errorf("unsupported dump format version: %d", version)
+	}
+
+	return version, nil
+}
+
+func decodeDumpBatch(buff *bytes.Buffer, version byte) ([]database.DumpElem, error) {
+	decoder := gob.NewDecoder(buff)
+
+	if version == dumpFormatV1 {
+		var batch []dumpElemV1
+		if err := decoder.Decode(&batch); err != nil {
+			return nil, err
+		}
+
+		elems := make([]database.DumpElem, 0, len(batch))
+		for _, elem := range batch {
+			elems = append(elems, database.DumpElem{
+				Key:       elem.Key,
+				BatchSize: elem.BatchSize,
+				Value:     database.ValueType(elem.Value),
+				TxAt:      elem.TxAt,
+				Tx:        elem.Tx,
+			})
+		}
+
+		return elems, nil
+	}
+
+	var batch []database.DumpElem
+	if err := decoder.Decode(&batch); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
@@ -2,7 +2,6 @@ package dumper
 
 import (
 	"bytes"
-	"encoding/gob"
 	"errors"
 	"fmt"
 	"os"
@@ -13,6 +12,7 @@ import (
 
 type readSession struct {
 	buff        *bytes.Buffer
+	version     byte // dump format version
 	closed      bool
 	dumpVersion uint64    // dump version when session was created
 	lastAccess  time.Time // last access time to the session
@@ -30,7 +30,7 @@ func (d *Dumper) GetNextData(sessionUUID string) ([]database.DumpElem, bool, err
 	}
 	d.sessMu.Unlock()
 
-	buff, err := d.getSessionBuff(sessionUUID)
+	buff, version, err := d.getSessionBuff(sessionUUID)
 	if err != nil {
 		return nil, false, err
 	}
@@ -57,9 +57,8 @@ func (d *Dumper) GetNextData(sessionUUID string) ([]database.DumpElem, bool, err
 		return nil, false, nil
 	}
 
-	var batch []database.DumpElem
-	decoder := gob.NewDecoder(buff)
-	if err := decoder.Decode(&batch); err != nil {
+	batch, err := decodeDumpBatch(buff, version)
+	if err != nil {
 		d.CloseReadSession(sessionUUID)
 
 		return nil, false, fmt.Errorf("decode batch: %w", err)
@@ -91,7 +90,7 @@ func (d *Dumper) CloseReadSession(sessionUUID string) {
 	}
 }
 
-func (d *Dumper) getSessionBuff(sessionUUID string) (*bytes.Buffer, error) {
+func (d *Dumper) getSessionBuff(sessionUUID string) (*bytes.Buffer, byte, error) {
 	d.sessMu.Lock()
 	defer d.sessMu.Unlock()
 
@@ -110,18 +109,26 @@ func (d *Dumper) getSessionBuff(sessionUUID string) (*bytes.Buffer, error) {
 			if errors.Is(err, os.ErrNotExist) {
 				sess = readSession{
 					buff:        bytes.NewBuffer(nil),
+					version:     currentDumpFormat,
 					dumpVersion: currentVersion,
 					lastAccess:  time.Now(),
 				}
 				d.sessions[sessionUUID] = sess
 				d.activeSessions++
-				return sess.buff, nil
+				return sess.buff, sess.version, nil
 			}
-			return nil, fmt.Errorf("failed to open dump file: %w", err)
+			return nil, 0, fmt.Errorf("failed to open dump file: %w", err)
+		}
+
+		buff := bytes.NewBuffer(data)
+		format, err := readDumpHeader(buff)
+		if err != nil {
+			return nil, 0, err
 		}
 
 		sess = readSession{
-			buff:        bytes.NewBuffer(data),
+			buff:        buff,
+			version:     format,
 			dumpVersion: currentVersion,
 			lastAccess:  time.Now(),
 		}
@@ -130,8 +137,8 @@ func (d *Dumper) getSessionBuff(sessionUUID string) (*bytes.Buffer, error) {
 	}
 
 	if sess.closed {
-		return nil, database.ErrDumpReadSessionClosed
+		return nil, 0, database.ErrDumpReadSessionClosed
 	}
 
-	return sess.buff, nil
+	return sess.buff, sess.version, nil
 }
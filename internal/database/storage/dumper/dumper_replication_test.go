@@ -26,7 +26,7 @@ func TestDumper_GetNextData(t *testing.T) {
 			FromWAL:  false,
 		},
 		database.BatchKey{
-			BatchSize:    60,
+			Window:       database.NewSecondsWindow(60),
 			BatchSizeStr: "60",
 			Key:          "key1",
 		},
@@ -3,7 +3,6 @@ package dumper
 import (
 	"bytes"
 	"context"
-	"encoding/gob"
 	"errors"
 	"fmt"
 	"io"
@@ -43,18 +42,21 @@ func (d *Dumper) Restore(ctx context.Context) (database.Tx, error) {
 	batchCount := 0
 
 	buffer := bytes.NewBuffer(data)
+	version, err := readDumpHeader(buffer)
+	if err != nil {
+		return 0, err
+	}
+
 	for buffer.Len() > 0 {
 		select {
 		case <-ctx.Done():
 			return 0, ctx.Err()
 		default:
-			var batch []database.DumpElem
-			decoder := gob.NewDecoder(buffer)
-
 			// Save position before decoding for potential skipping of corrupted batches
 			startPos := buffer.Len()
 
-			if err := decoder.Decode(&batch); err != nil {
+			batch, err := decodeDumpBatch(buffer, version)
+			if err != nil {
 				// If it's not end of file and there's data, try to skip corrupted batch
 				if buffer.Len() > 0 && !errors.Is(err, io.EOF) {
 					// Try to find start of next batch, skipping corrupted data
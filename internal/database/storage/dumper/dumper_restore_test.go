@@ -0,0 +1,121 @@
+package dumper
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+	inMemory "fq/internal/database/storage/engine/in-memory"
+)
+
+func TestDumper_RestoreLegacyFormat(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := inMemory.NewEngine(inMemory.HashTableBuilder, 1, &logger, nil, nil)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	now := database.TxTime(time.Now().Unix())
+
+	var buffer bytes.Buffer
+	err = gob.NewEncoder(&buffer).Encode([]dumpElemV1{
+		{Key: "key1", BatchSize: 60, Value: 7, TxAt: now, Tx: 5},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, currentDumpFileName), buffer.Bytes(), 0o600))
+
+	d := New(engine, nil, dir)
+	defer d.Shutdown()
+
+	lastTx, err := d.Restore(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, database.Tx(5), lastTx)
+
+	value, found := engine.Get(database.BatchKey{Key: "key1", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"})
+	require.True(t, found)
+	require.Equal(t, database.ValueType(7), value)
+}
+
+func TestDumper_RestoreV2Format(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := inMemory.NewEngine(inMemory.HashTableBuilder, 1, &logger, nil, nil)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	now := database.TxTime(time.Now().Unix())
+
+	var buffer bytes.Buffer
+	buffer.Write(dumpMagic)
+	buffer.WriteByte(dumpFormatV2)
+	// elements of V2 dumps have no window specs, they are decoded into database.DumpElem by field names
+	type dumpElemV2 struct {
+		Key       string
+		BatchSize uint32
+		Value     database.ValueType
+		TxAt      database.TxTime
+		Tx        database.Tx
+	}
+
+	err = gob.NewEncoder(&buffer).Encode([]dumpElemV2{
+		{Key: "key1", BatchSize: 60, Value: 1 << 40, TxAt: now, Tx: 8},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, currentDumpFileName), buffer.Bytes(), 0o600))
+
+	d := New(engine, nil, dir)
+	defer d.Shutdown()
+
+	lastTx, err := d.Restore(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, database.Tx(8), lastTx)
+
+	value, found := engine.Get(database.BatchKey{Key: "key1", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"})
+	require.True(t, found)
+	require.Equal(t, database.ValueType(1<<40), value)
+}
+
+func TestDumper_DumpAndRestore(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := inMemory.NewEngine(inMemory.HashTableBuilder, 1, &logger, nil, nil)
+	require.NoError(t, err)
+
+	key := database.BatchKey{Key: "key1", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"}
+	txCtx := database.TxContext{Tx: 1, CurrTime: database.TxTime(time.Now().Unix())}
+	_, err = engine.IncrBy(txCtx, key, 1<<40)
+	require.NoError(t, err)
+
+	window, err := database.ParseWindow("1d@Europe/Berlin")
+	require.NoError(t, err)
+	calendarKey := database.BatchKey{Key: "key1", Window: window, BatchSizeStr: window.String()}
+	engine.Incr(txCtx, calendarKey)
+
+	dir := t.TempDir()
+	d := New(engine, nil, dir)
+	defer d.Shutdown()
+	require.NoError(t, d.Dump(context.Background(), 1))
+
+	restored, err := inMemory.NewEngine(inMemory.HashTableBuilder, 1, &logger, nil, nil)
+	require.NoError(t, err)
+
+	r := New(restored, nil, dir)
+	defer r.Shutdown()
+
+	lastTx, err := r.Restore(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, database.Tx(1), lastTx)
+
+	value, found := restored.Get(key)
+	require.True(t, found)
+	require.Equal(t, database.ValueType(1<<40), value)
+
+	value, found = restored.Get(calendarKey)
+	require.True(t, found)
+	require.Equal(t, database.ValueType(1), value)
+}
@@ -8,81 +8,310 @@ import (
 )
 
 type FqElem struct {
-	ver      database.Tx
-	value    database.ValueType
-	lastTxAt database.TxTime
+	ver       database.Tx
+	value     database.ValueType
+	prevValue database.ValueType // value of the batch preceding the batch of lastTxAt
+	lastTxAt  database.TxTime
+	mode      database.WindowMode
+	history   *windowHistory // closed windows preceding the batch of prevValue, nil if disabled
 
-	dumpVer      database.Tx
-	dumpValue    database.ValueType
-	dumpLastTxAt database.TxTime
+	dumpVer       database.Tx
+	dumpValue     database.ValueType
+	dumpPrevValue database.ValueType
+	dumpLastTxAt  database.TxTime
+	dumpMode      database.WindowMode
+	dumpHistory   *windowHistory
 
-	batchSize database.TxTime
-	mu        sync.RWMutex
+	window database.Window
+	mu     sync.RWMutex
 }
 
-func NewFqElem(batchSize uint32) *FqElem {
+func NewFqElem(window database.Window) *FqElem {
 	return &FqElem{
-		batchSize: database.TxTime(batchSize),
-		ver:       database.NoTx,
-		dumpVer:   database.NoTx,
+		window:  window,
+		ver:     database.NoTx,
+		dumpVer: database.NoTx,
 	}
 }
 
-func (e *FqElem) Incr(txCtx database.TxContext) database.ValueType {
-	batchStartsAt := startOfBatch(txCtx.CurrTime, e.batchSize)
+// copyState returns a new element with the counter state of e.
+// The history is not copied, it doesn't affect values of the counter.
+func (e *FqElem) copyState() *FqElem {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return &FqElem{
+		ver:       e.ver,
+		value:     e.value,
+		prevValue: e.prevValue,
+		lastTxAt:  e.lastTxAt,
+		mode:      e.mode,
+		dumpVer:   e.dumpVer,
+		window:    e.window,
+	}
+}
+
+func (e *FqElem) Incr(txCtx database.TxContext, mode database.WindowMode) database.ValueType {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.incrLocked(txCtx, 1, mode)
+}
+
+// IncrBy adds delta to the counter. It returns database.ErrValueOverflow
+// and leaves the counter untouched if the result doesn't fit into database.ValueType.
+func (e *FqElem) IncrBy(
+	txCtx database.TxContext,
+	delta database.ValueType,
+	mode database.WindowMode,
+) (database.ValueType, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if value, _ := e.batchValuesLocked(txCtx.CurrTime); value > database.MaxValue-delta {
+		return e.valueAtLocked(txCtx.CurrTime, mode), database.ErrValueOverflow
+	}
+
+	return e.incrLocked(txCtx, delta, mode), nil
+}
 
+// CapIncr increments the counter only if its value in the current batch is below the limit.
+// It returns the resulting value and whether the increment was applied.
+func (e *FqElem) CapIncr(
+	txCtx database.TxContext,
+	limit database.ValueType,
+	mode database.WindowMode,
+) (database.ValueType, bool) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	value := e.value
-	if e.lastTxAt < batchStartsAt {
-		value = 0
+	if value := e.valueAtLocked(txCtx.CurrTime, mode); value >= limit {
+		return value, false
+	}
+
+	return e.incrLocked(txCtx, 1, mode), true
+}
+
+func (e *FqElem) incrLocked(
+	txCtx database.TxContext,
+	delta database.ValueType,
+	mode database.WindowMode,
+) database.ValueType {
+	newValue, newPrevValue, newLastTxAt, ok := e.incrementedLocked(txCtx.CurrTime, delta)
+	if !ok {
+		return e.valueAtLocked(txCtx.CurrTime, mode)
+	}
+
+	newMode := e.mode
+	if mode != database.WindowModeDefault {
+		newMode = mode
 	}
 
 	if e.dumpVer != txCtx.DumpTx {
 		if txCtx.Tx == txCtx.DumpTx {
-			e.dumpValue = value + 1
+			e.dumpValue = newValue
+			e.dumpPrevValue = newPrevValue
 			e.dumpVer = txCtx.Tx
-			e.dumpLastTxAt = txCtx.CurrTime
+			e.dumpLastTxAt = newLastTxAt
+			e.dumpMode = newMode
 		} else {
 			e.dumpValue = e.value
+			e.dumpPrevValue = e.prevValue
 			e.dumpVer = e.ver
 			e.dumpLastTxAt = e.lastTxAt
+			e.dumpMode = e.mode
+			e.dumpHistory = e.history.clone()
 		}
 	}
 
-	e.value = value + 1
+	e.archiveLocked(txCtx.CurrTime)
+	if txCtx.Tx == txCtx.DumpTx && e.dumpVer == txCtx.Tx {
+		e.dumpHistory = e.history.clone()
+	}
+
+	e.value = newValue
+	e.prevValue = newPrevValue
 	e.ver = txCtx.Tx
-	e.lastTxAt = txCtx.CurrTime
+	e.lastTxAt = newLastTxAt
+	e.mode = newMode
+
+	return e.valueAtLocked(txCtx.CurrTime, mode)
+}
+
+// archiveLocked moves values of batches which are not retained at currTime anymore to the history.
+func (e *FqElem) archiveLocked(currTime database.TxTime) {
+	if e.history == nil {
+		return
+	}
+
+	batchStartsAt := e.window.Start(int64(currTime))
+	lastBatchStartsAt := e.window.Start(int64(e.lastTxAt))
+
+	if batchStartsAt <= lastBatchStartsAt {
+		return
+	}
+
+	if lastBatchStartsAt > 0 {
+		e.history.push(database.HistoryEntry{Start: e.window.Start(lastBatchStartsAt - 1), Value: e.prevValue})
+	}
+
+	if e.window.Start(batchStartsAt-1) != lastBatchStartsAt {
+		e.history.push(database.HistoryEntry{Start: lastBatchStartsAt, Value: e.value})
+	}
+}
+
+// incrementedLocked returns the state of the element after adding delta at currTime.
+// An event older than lastTxAt is added to the batch it belongs to if the batch is retained,
+// otherwise ok is false.
+func (e *FqElem) incrementedLocked(
+	currTime database.TxTime,
+	delta database.ValueType,
+) (value, prevValue database.ValueType, lastTxAt database.TxTime, ok bool) {
+	batchStartsAt := e.window.Start(int64(currTime))
+	lastBatchStartsAt := e.window.Start(int64(e.lastTxAt))
+
+	switch {
+	case batchStartsAt >= lastBatchStartsAt:
+		value, prevValue = e.batchValuesLocked(currTime)
 
-	return e.value
+		return value + delta, prevValue, max(currTime, e.lastTxAt), true
+	case lastBatchStartsAt > 0 && batchStartsAt == e.window.Start(lastBatchStartsAt-1):
+		return e.value, e.prevValue + delta, e.lastTxAt, true
+	default:
+		return 0, 0, 0, false
+	}
+}
+
+// batchValuesLocked returns values of the batch containing currTime and of the batch preceding it.
+func (e *FqElem) batchValuesLocked(currTime database.TxTime) (value, prevValue database.ValueType) {
+	batchStartsAt := e.window.Start(int64(currTime))
+	lastBatchStartsAt := e.window.Start(int64(e.lastTxAt))
+
+	switch {
+	case lastBatchStartsAt == batchStartsAt:
+		return e.value, e.prevValue
+	case lastBatchStartsAt < batchStartsAt:
+		if batchStartsAt > 0 && lastBatchStartsAt == e.window.Start(batchStartsAt-1) {
+			return 0, e.value
+		}
+
+		return 0, 0
+	default:
+		// currTime is older than the last event, the batch before it is not retained
+		if lastBatchStartsAt > 0 && batchStartsAt == e.window.Start(lastBatchStartsAt-1) {
+			return e.prevValue, 0
+		}
+
+		return 0, 0
+	}
+}
+
+func (e *FqElem) valueAtLocked(currTime database.TxTime, mode database.WindowMode) database.ValueType {
+	value, prevValue := e.batchValuesLocked(currTime)
+
+	if mode == database.WindowModeDefault {
+		mode = e.mode
+	}
+
+	if mode != database.WindowModeSliding {
+		return value
+	}
+
+	return slidingValue(value, prevValue, currTime, e.window)
+}
+
+func (e *FqElem) Value(mode database.WindowMode) database.ValueType {
+	now := time.Now().Unix()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.valueAtLocked(database.TxTime(now), mode)
 }
 
-func (e *FqElem) Value() database.ValueType {
+// History returns values of n closed windows preceding the current one from the oldest to the newest.
+func (e *FqElem) History(n int) []database.HistoryEntry {
 	now := time.Now().Unix()
-	batchStartsAt := startOfBatch(database.TxTime(now), e.batchSize)
 
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	if e.lastTxAt < batchStartsAt {
-		return 0
+	return e.historyAtLocked(now, n)
+}
+
+func (e *FqElem) historyAtLocked(now int64, n int) []database.HistoryEntry {
+	values := make(map[int64]database.ValueType, n)
+	for _, entry := range e.history.list() {
+		values[entry.Start] = entry.Value
+	}
+
+	if lastBatchStartsAt := e.window.Start(int64(e.lastTxAt)); lastBatchStartsAt > 0 {
+		values[e.window.Start(lastBatchStartsAt-1)] = e.prevValue
+		if lastBatchStartsAt < e.window.Start(now) {
+			values[lastBatchStartsAt] = e.value
+		}
+	}
+
+	res := make([]database.HistoryEntry, n)
+	start := e.window.Start(now)
+	for i := n - 1; i >= 0; i-- {
+		start = e.window.Start(start - 1)
+		res[i] = database.HistoryEntry{Start: start, Value: values[start]}
 	}
 
-	return e.value
+	return res
 }
 
-func (e *FqElem) DumpValue(dumpTx database.Tx) (database.ValueType, database.TxTime, database.Tx) {
+// DumpValue returns the state of the element as of dumpTx.
+// ok is false if the element didn't exist at that moment.
+func (e *FqElem) DumpValue(dumpTx database.Tx) (elem database.DumpElem, ok bool) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
+	elem.BatchSize = e.window.Seconds()
+	elem.Window = e.window.String()
+
 	if e.ver <= dumpTx {
-		return e.value, e.lastTxAt, e.ver
+		elem.Value = e.value
+		elem.PrevValue = e.prevValue
+		elem.Mode = e.mode
+		elem.TxAt = e.lastTxAt
+		elem.Tx = e.ver
+		elem.History = e.history.list()
+
+		return elem, true
 	}
 
 	if e.dumpVer <= dumpTx {
-		return e.dumpValue, e.dumpLastTxAt, e.dumpVer
+		elem.Value = e.dumpValue
+		elem.PrevValue = e.dumpPrevValue
+		elem.Mode = e.dumpMode
+		elem.TxAt = e.dumpLastTxAt
+		elem.Tx = e.dumpVer
+		elem.History = e.dumpHistory.list()
+
+		return elem, true
+	}
+
+	return database.DumpElem{}, false
+}
+
+// slidingValue approximates the number of events during the last window
+// assuming that events of the previous batch were distributed evenly.
+func slidingValue(value, prevValue database.ValueType, currTime database.TxTime, window database.Window) database.ValueType {
+	if prevValue == 0 {
+		return value
+	}
+
+	batchStartsAt := window.Start(int64(currTime))
+	batchSize := window.End(int64(currTime)) - batchStartsAt
+	elapsed := int64(currTime) - batchStartsAt
+	weight := float64(batchSize-elapsed) / float64(batchSize)
+	weighted := database.ValueType(float64(prevValue) * weight)
+
+	if value > database.MaxValue-weighted {
+		return database.MaxValue
 	}
 
-	return database.ErrorValue, 0, 0
+	return value + weighted
 }
@@ -10,26 +10,26 @@ import (
 )
 
 func TestNewElem(t *testing.T) {
-	e := NewFqElem(60)
+	e := NewFqElem(database.NewSecondsWindow(60))
 
-	require.Equal(t, e.batchSize, database.TxTime(60))
+	require.Equal(t, e.window, database.NewSecondsWindow(60))
 	require.Equal(t, e.ver, database.NoTx)
 	require.Equal(t, e.dumpVer, database.NoTx)
 }
 
 func TestElem_Incr(t *testing.T) {
-	e := NewFqElem(60)
+	e := NewFqElem(database.NewSecondsWindow(60))
 	currTime := database.TxTime(time.Now().Unix())
 
 	t.Run("no dump tx", func(t *testing.T) {
-		curr := e.Incr(database.TxContext{Tx: 1000, DumpTx: database.NoTx, CurrTime: currTime})
+		curr := e.Incr(database.TxContext{Tx: 1000, DumpTx: database.NoTx, CurrTime: currTime}, database.WindowModeDefault)
 		require.Equal(t, database.ValueType(1), curr)
 		require.Equal(t, database.ValueType(1), e.value)
 		require.Equal(t, database.Tx(1000), e.ver)
 		require.Equal(t, database.NoTx, e.dumpVer)
 		require.Equal(t, database.ValueType(0), e.dumpValue)
 
-		curr = e.Incr(database.TxContext{Tx: 1001, DumpTx: database.NoTx, CurrTime: currTime})
+		curr = e.Incr(database.TxContext{Tx: 1001, DumpTx: database.NoTx, CurrTime: currTime}, database.WindowModeDefault)
 		require.Equal(t, database.ValueType(2), curr)
 		require.Equal(t, database.ValueType(2), e.value)
 		require.Equal(t, database.Tx(1001), e.ver)
@@ -38,7 +38,7 @@ func TestElem_Incr(t *testing.T) {
 	})
 
 	t.Run("tx = dump tx", func(t *testing.T) {
-		curr := e.Incr(database.TxContext{Tx: 1002, DumpTx: 1002, CurrTime: currTime})
+		curr := e.Incr(database.TxContext{Tx: 1002, DumpTx: 1002, CurrTime: currTime}, database.WindowModeDefault)
 		require.Equal(t, database.ValueType(3), curr)
 		require.Equal(t, database.ValueType(3), e.value)
 		require.Equal(t, database.Tx(1002), e.ver)
@@ -47,14 +47,14 @@ func TestElem_Incr(t *testing.T) {
 	})
 
 	t.Run("tx > dump tx", func(t *testing.T) {
-		curr := e.Incr(database.TxContext{Tx: 1003, DumpTx: 1002, CurrTime: currTime})
+		curr := e.Incr(database.TxContext{Tx: 1003, DumpTx: 1002, CurrTime: currTime}, database.WindowModeDefault)
 		require.Equal(t, database.ValueType(4), curr)
 		require.Equal(t, database.ValueType(4), e.value)
 		require.Equal(t, database.Tx(1003), e.ver)
 		require.Equal(t, database.Tx(1002), e.dumpVer)
 		require.Equal(t, database.ValueType(3), e.dumpValue)
 
-		curr = e.Incr(database.TxContext{Tx: 1004, DumpTx: 1003, CurrTime: currTime})
+		curr = e.Incr(database.TxContext{Tx: 1004, DumpTx: 1003, CurrTime: currTime}, database.WindowModeDefault)
 		require.Equal(t, database.ValueType(5), curr)
 		require.Equal(t, database.ValueType(5), e.value)
 		require.Equal(t, database.Tx(1004), e.ver)
@@ -63,49 +63,201 @@ func TestElem_Incr(t *testing.T) {
 	})
 
 	t.Run("current batch changed", func(t *testing.T) {
-		e := NewFqElem(1)
-		curr := e.Incr(database.TxContext{Tx: 1000, DumpTx: database.NoTx, CurrTime: database.TxTime(time.Now().Unix())})
+		e := NewFqElem(database.NewSecondsWindow(1))
+		curr := e.Incr(database.TxContext{Tx: 1000, DumpTx: database.NoTx, CurrTime: database.TxTime(time.Now().Unix())}, database.WindowModeDefault)
 		require.Equal(t, database.ValueType(1), curr)
-		curr = e.Incr(database.TxContext{Tx: 1001, DumpTx: database.NoTx, CurrTime: database.TxTime(time.Now().Unix())})
+		curr = e.Incr(database.TxContext{Tx: 1001, DumpTx: database.NoTx, CurrTime: database.TxTime(time.Now().Unix())}, database.WindowModeDefault)
 		require.Equal(t, database.ValueType(2), curr)
 
 		time.Sleep(time.Millisecond * 1200)
-		curr = e.Incr(database.TxContext{Tx: 1002, DumpTx: database.NoTx, CurrTime: database.TxTime(time.Now().Unix())})
+		curr = e.Incr(database.TxContext{Tx: 1002, DumpTx: database.NoTx, CurrTime: database.TxTime(time.Now().Unix())}, database.WindowModeDefault)
+		require.Equal(t, database.ValueType(1), curr)
+	})
+}
+
+func TestElem_IncrBy(t *testing.T) {
+	e := NewFqElem(database.NewSecondsWindow(60))
+	currTime := database.TxTime(time.Now().Unix())
+
+	curr, err := e.IncrBy(database.TxContext{Tx: 1000, DumpTx: database.NoTx, CurrTime: currTime}, 5, database.WindowModeDefault)
+	require.NoError(t, err)
+	require.Equal(t, database.ValueType(5), curr)
+
+	curr, err = e.IncrBy(database.TxContext{Tx: 1001, DumpTx: 1001, CurrTime: currTime}, 3, database.WindowModeDefault)
+	require.NoError(t, err)
+	require.Equal(t, database.ValueType(8), curr)
+	require.Equal(t, database.ValueType(8), e.dumpValue)
+
+	curr, err = e.IncrBy(database.TxContext{Tx: 1002, DumpTx: 1001, CurrTime: currTime}, database.MaxValue, database.WindowModeDefault)
+	require.ErrorIs(t, err, database.ErrValueOverflow)
+	require.Equal(t, database.ValueType(8), curr)
+	require.Equal(t, database.Tx(1001), e.ver)
+
+	elem, ok := e.DumpValue(1001)
+	require.True(t, ok)
+	require.Equal(t, database.ValueType(8), elem.Value)
+}
+
+func TestElem_CapIncr(t *testing.T) {
+	e := NewFqElem(database.NewSecondsWindow(60))
+	currTime := database.TxTime(time.Now().Unix())
+
+	curr, ok := e.CapIncr(database.TxContext{Tx: 1000, DumpTx: database.NoTx, CurrTime: currTime}, 2, database.WindowModeDefault)
+	require.True(t, ok)
+	require.Equal(t, database.ValueType(1), curr)
+
+	curr, ok = e.CapIncr(database.TxContext{Tx: 1001, DumpTx: database.NoTx, CurrTime: currTime}, 2, database.WindowModeDefault)
+	require.True(t, ok)
+	require.Equal(t, database.ValueType(2), curr)
+
+	curr, ok = e.CapIncr(database.TxContext{Tx: 1002, DumpTx: database.NoTx, CurrTime: currTime}, 2, database.WindowModeDefault)
+	require.False(t, ok)
+	require.Equal(t, database.ValueType(2), curr)
+	require.Equal(t, database.Tx(1001), e.ver)
+
+	t.Run("current batch changed", func(t *testing.T) {
+		curr, ok := e.CapIncr(database.TxContext{Tx: 1003, DumpTx: database.NoTx, CurrTime: currTime + 60}, 2, database.WindowModeDefault)
+		require.True(t, ok)
 		require.Equal(t, database.ValueType(1), curr)
 	})
 }
 
 func TestElem_Value(t *testing.T) {
-	e := NewFqElem(60)
-	e.Incr(database.TxContext{Tx: 1000, DumpTx: database.NoTx})
+	e := NewFqElem(database.NewSecondsWindow(60))
+	e.Incr(database.TxContext{Tx: 1000, DumpTx: database.NoTx}, database.WindowModeDefault)
 	require.Equal(t, database.ValueType(1), e.value)
-	e.Incr(database.TxContext{Tx: 1000, DumpTx: database.Tx(1000)})
+	e.Incr(database.TxContext{Tx: 1000, DumpTx: database.Tx(1000)}, database.WindowModeDefault)
 	require.Equal(t, database.ValueType(2), e.value)
 }
 
 func TestElem_DumpValue(t *testing.T) {
 	now := database.TxTime(time.Now().Unix())
 
-	e := NewFqElem(60)
-	e.Incr(database.TxContext{Tx: 1000, DumpTx: database.NoTx, CurrTime: now})
-	v, lastTime, tx := e.DumpValue(1000)
-	require.Equal(t, database.ValueType(1), v)
-	require.Equal(t, now, lastTime)
-	require.Equal(t, database.Tx(1000), tx)
-
-	v, lastTime, tx = e.DumpValue(999)
-	require.Equal(t, database.ValueType(0), v)
-	require.Equal(t, database.TxTime(0), lastTime)
-	require.Equal(t, database.Tx(0), tx)
-
-	e.Incr(database.TxContext{Tx: 1001, DumpTx: database.Tx(1001), CurrTime: now})
-	v, lastTime, tx = e.DumpValue(1000)
-	require.Equal(t, database.ErrorValue, v)
-	require.Equal(t, database.TxTime(0), lastTime)
-	require.Equal(t, database.Tx(0), tx)
-
-	v, lastTime, tx = e.DumpValue(1001)
-	require.Equal(t, database.ValueType(2), v)
-	require.Equal(t, now, lastTime)
-	require.Equal(t, database.Tx(1001), tx)
+	e := NewFqElem(database.NewSecondsWindow(60))
+	e.Incr(database.TxContext{Tx: 1000, DumpTx: database.NoTx, CurrTime: now}, database.WindowModeDefault)
+	elem, ok := e.DumpValue(1000)
+	require.True(t, ok)
+	require.Equal(t, database.ValueType(1), elem.Value)
+	require.Equal(t, now, elem.TxAt)
+	require.Equal(t, database.Tx(1000), elem.Tx)
+
+	elem, ok = e.DumpValue(999)
+	require.True(t, ok)
+	require.Equal(t, database.ValueType(0), elem.Value)
+	require.Equal(t, database.TxTime(0), elem.TxAt)
+	require.Equal(t, database.Tx(0), elem.Tx)
+
+	e.Incr(database.TxContext{Tx: 1001, DumpTx: database.Tx(1001), CurrTime: now}, database.WindowModeSliding)
+	_, ok = e.DumpValue(1000)
+	require.False(t, ok)
+
+	elem, ok = e.DumpValue(1001)
+	require.True(t, ok)
+	require.Equal(t, database.ValueType(2), elem.Value)
+	require.Equal(t, database.WindowModeSliding, elem.Mode)
+	require.Equal(t, now, elem.TxAt)
+	require.Equal(t, database.Tx(1001), elem.Tx)
+}
+
+func TestElem_Sliding(t *testing.T) {
+	batchStart := database.TxTime(time.Now().Unix()) / 100 * 100
+
+	e := NewFqElem(database.NewSecondsWindow(100))
+	_, err := e.IncrBy(database.TxContext{Tx: 1000, CurrTime: batchStart - 50}, 10, database.WindowModeSliding)
+	require.NoError(t, err)
+
+	// a quarter of the current batch has elapsed, so 3/4 of the previous batch value is taken into account
+	value := e.valueAtLocked(batchStart+25, database.WindowModeDefault)
+	require.Equal(t, database.ValueType(7), value)
+
+	value = e.valueAtLocked(batchStart+25, database.WindowModeFixed)
+	require.Equal(t, database.ValueType(0), value)
+
+	curr, ok := e.CapIncr(database.TxContext{Tx: 1001, CurrTime: batchStart + 25}, 8, database.WindowModeDefault)
+	require.True(t, ok)
+	require.Equal(t, database.ValueType(8), curr)
+
+	curr, ok = e.CapIncr(database.TxContext{Tx: 1002, CurrTime: batchStart + 25}, 8, database.WindowModeDefault)
+	require.False(t, ok)
+	require.Equal(t, database.ValueType(8), curr)
+	require.Equal(t, database.ValueType(1), e.value)
+	require.Equal(t, database.ValueType(10), e.prevValue)
+
+	// two batches later nothing is left
+	value = e.valueAtLocked(batchStart+225, database.WindowModeDefault)
+	require.Equal(t, database.ValueType(0), value)
+}
+
+func TestElem_OutOfOrder(t *testing.T) {
+	e := NewFqElem(database.NewSecondsWindow(100))
+	batchStart := database.TxTime(time.Now().Unix()) / 100 * 100
+
+	curr := e.Incr(database.TxContext{Tx: 1, CurrTime: batchStart + 50}, database.WindowModeDefault)
+	require.Equal(t, database.ValueType(1), curr)
+
+	// late event of the same batch
+	curr = e.Incr(database.TxContext{Tx: 2, CurrTime: batchStart + 10}, database.WindowModeDefault)
+	require.Equal(t, database.ValueType(2), curr)
+	require.Equal(t, batchStart+50, e.lastTxAt)
+
+	// late event of the previous batch
+	curr = e.Incr(database.TxContext{Tx: 3, CurrTime: batchStart - 10}, database.WindowModeDefault)
+	require.Equal(t, database.ValueType(1), curr)
+	require.Equal(t, database.ValueType(2), e.value)
+	require.Equal(t, database.ValueType(1), e.prevValue)
+	require.Equal(t, batchStart+50, e.lastTxAt)
+
+	// the batch before the previous one is not retained
+	curr = e.Incr(database.TxContext{Tx: 4, CurrTime: batchStart - 110}, database.WindowModeDefault)
+	require.Equal(t, database.ValueType(0), curr)
+	require.Equal(t, database.Tx(3), e.ver)
+
+	require.Equal(t, database.ValueType(2), e.valueAtLocked(batchStart+60, database.WindowModeFixed))
+	require.Equal(t, database.ValueType(2), e.valueAtLocked(batchStart+60, database.WindowModeSliding))
+	require.Equal(t, database.ValueType(2), e.valueAtLocked(batchStart+50, database.WindowModeSliding))
+}
+
+func TestElem_History(t *testing.T) {
+	e := NewFqElem(database.NewSecondsWindow(100))
+	e.history = newWindowHistory(3)
+	batchStart := database.TxTime(time.Now().Unix()) / 100 * 100
+
+	incr := func(tx database.Tx, currTime database.TxTime, n int) {
+		for i := 0; i < n; i++ {
+			e.Incr(database.TxContext{Tx: tx, CurrTime: currTime}, database.WindowModeDefault)
+		}
+	}
+
+	incr(1, batchStart-490, 2)
+	incr(2, batchStart-300, 1)
+	incr(3, batchStart-200, 3)
+	incr(4, batchStart-100, 1)
+
+	require.Equal(t, []database.HistoryEntry{
+		{Start: int64(batchStart - 500), Value: 2},
+		{Start: int64(batchStart - 300), Value: 1},
+	}, e.history.list())
+
+	require.Equal(t, []database.HistoryEntry{
+		{Start: int64(batchStart - 500), Value: 2},
+		{Start: int64(batchStart - 400), Value: 0},
+		{Start: int64(batchStart - 300), Value: 1},
+		{Start: int64(batchStart - 200), Value: 3},
+		{Start: int64(batchStart - 100), Value: 1},
+	}, e.historyAtLocked(int64(batchStart+10), 5))
+
+	// the current batch is not closed yet
+	incr(5, batchStart+10, 1)
+	require.Equal(t, []database.HistoryEntry{
+		{Start: int64(batchStart - 200), Value: 3},
+		{Start: int64(batchStart - 100), Value: 1},
+	}, e.historyAtLocked(int64(batchStart+20), 2))
+
+	elem, ok := e.DumpValue(5)
+	require.True(t, ok)
+	require.Equal(t, []database.HistoryEntry{
+		{Start: int64(batchStart - 500), Value: 2},
+		{Start: int64(batchStart - 300), Value: 1},
+		{Start: int64(batchStart - 200), Value: 3},
+	}, elem.History)
 }
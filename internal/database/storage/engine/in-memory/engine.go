@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -26,16 +27,34 @@ var (
 
 type hashTable interface {
 	Incr(txCtx database.TxContext, key database.BatchKey) database.ValueType
+	IncrBy(txCtx database.TxContext, key database.BatchKey, delta database.ValueType) (database.ValueType, error)
+	CapIncr(txCtx database.TxContext, key database.BatchKey, limit database.ValueType) (database.ValueType, bool)
+	Throttle(txCtx database.TxContext, key database.ThrottleKey, now int64) database.ThrottleResult
+	SetThrottle(txCtx database.TxContext, key string, tat int64)
 	Get(key database.BatchKey) (database.ValueType, bool)
-	Del(key database.BatchKey) bool
+	History(key database.BatchKey, n int) ([]database.HistoryEntry, error)
+	Del(txCtx database.TxContext, key database.BatchKey) bool
+	Watch(key database.BatchKey) (<-chan struct{}, func())
+	getOrInitElem(key hashTableKey) *FqElem
+	writeElem(txCtx database.TxContext, key hashTableKey) *FqElem
+	notify(key hashTableKey)
 	Clean(ctx context.Context)
+	Reset()
 	Dump(ctx context.Context, dumpTx database.Tx, ch chan<- database.DumpElem)
-	RestoreDumpElem(elem database.DumpElem)
+	RestoreDumpElem(elem database.DumpElem, window database.Window)
+}
+
+type commandRegistry interface {
+	CommandByID(compute.CommandID) (compute.Command, bool)
+	SetReplay(compute.CommandID, compute.ReplayFunc) error
 }
 
 type Engine struct {
-	partitions []hashTable
-	logger     *zerolog.Logger
+	partitions    []hashTable
+	policies      *policyRegistry
+	subscriptions *subscriptionRegistry
+	commands      commandRegistry
+	logger        *zerolog.Logger
 }
 
 func NewEngine(
@@ -67,8 +86,16 @@ func NewEngine(
 	}
 
 	engine := &Engine{
-		partitions: partitions,
-		logger:     logger,
+		partitions:    partitions,
+		policies:      newPolicyRegistry(),
+		subscriptions: newSubscriptionRegistry(logger),
+		logger:        logger,
+	}
+
+	// WAL records are replayed by commands of the registry, builtin commands are replayed by the engine
+	engine.commands = compute.NewRegistry()
+	if err := engine.setBuiltinReplays(engine.commands); err != nil {
+		return nil, err
 	}
 
 	if walStream != nil {
@@ -91,7 +118,7 @@ func NewEngine(
 }
 
 func (e *Engine) Incr(txCtx database.TxContext, key database.BatchKey) database.ValueType {
-	if txCtx.FromWAL && isExpired(txCtx.CurrTime, database.TxTime(key.BatchSize)) {
+	if txCtx.FromWAL && isExpired(txCtx.CurrTime, key.Window) {
 		// expired value
 		return 0 // return 0 for WAL worker
 	}
@@ -99,6 +126,9 @@ func (e *Engine) Incr(txCtx database.TxContext, key database.BatchKey) database.
 	idx := e.partitionIdx(key.Key)
 	partition := e.partitions[idx]
 	value := partition.Incr(txCtx, key)
+	if !txCtx.DryRun {
+		e.subscriptions.notify(key, value-1, value)
+	}
 
 	if e.logger.GetLevel() == zerolog.DebugLevel {
 		e.logger.Debug().
@@ -111,6 +141,179 @@ func (e *Engine) Incr(txCtx database.TxContext, key database.BatchKey) database.
 	return value
 }
 
+func (e *Engine) IncrBy(
+	txCtx database.TxContext,
+	key database.BatchKey,
+	delta database.ValueType,
+) (database.ValueType, error) {
+	if txCtx.FromWAL && isExpired(txCtx.CurrTime, key.Window) {
+		return 0, nil
+	}
+
+	idx := e.partitionIdx(key.Key)
+	partition := e.partitions[idx]
+	value, err := partition.IncrBy(txCtx, key, delta)
+	if err == nil && !txCtx.DryRun {
+		e.subscriptions.notify(key, value-delta, value)
+	}
+
+	if e.logger.GetLevel() == zerolog.DebugLevel {
+		e.logger.Debug().
+			Any("tx_ctx", txCtx).
+			Any("key", key).
+			Any("delta", delta).
+			Any("value", value).
+			Err(err).
+			Msg("success incrby query")
+	}
+
+	return value, err
+}
+
+func (e *Engine) CapIncr(
+	txCtx database.TxContext,
+	key database.BatchKey,
+	limit database.ValueType,
+) (database.ValueType, bool) {
+	if txCtx.FromWAL && isExpired(txCtx.CurrTime, key.Window) {
+		return 0, false
+	}
+
+	idx := e.partitionIdx(key.Key)
+	partition := e.partitions[idx]
+	value, ok := partition.CapIncr(txCtx, key, limit)
+	if ok && !txCtx.DryRun {
+		e.subscriptions.notify(key, value-1, value)
+	}
+
+	if e.logger.GetLevel() == zerolog.DebugLevel {
+		e.logger.Debug().
+			Any("tx_ctx", txCtx).
+			Any("key", key).
+			Any("limit", limit).
+			Any("value", value).
+			Bool("allowed", ok).
+			Msg("success capincr query")
+	}
+
+	return value, ok
+}
+
+// MCapIncr increments all counters only if every one of them is below its limit.
+// Elements are locked in a deterministic order, so concurrent calls with overlapping keys don't deadlock.
+// Keys must be distinct.
+func (e *Engine) MCapIncr(
+	txCtx database.TxContext,
+	keys []database.BatchKey,
+	limits []database.ValueType,
+) ([]database.ValueType, bool) {
+	type cappedElem struct {
+		elem      *FqElem
+		batchKey  database.BatchKey
+		key       hashTableKey
+		partition int
+		limit     database.ValueType
+		mode      database.WindowMode
+		idx       int
+	}
+
+	elems := make([]cappedElem, 0, len(keys))
+	for i, key := range keys {
+		if txCtx.FromWAL && isExpired(txCtx.CurrTime, key.Window) {
+			continue
+		}
+
+		htKey := hashTableKey{key: key.Key, window: key.Window}
+		idx := e.partitionIdx(key.Key)
+		elems = append(elems, cappedElem{
+			elem:      e.partitions[idx].writeElem(txCtx, htKey),
+			batchKey:  key,
+			key:       htKey,
+			partition: idx,
+			limit:     limits[i],
+			mode:      key.Mode,
+			idx:       i,
+		})
+	}
+
+	sort.Slice(elems, func(i, j int) bool {
+		if elems[i].partition != elems[j].partition {
+			return elems[i].partition < elems[j].partition
+		}
+
+		if elems[i].key.key != elems[j].key.key {
+			return elems[i].key.key < elems[j].key.key
+		}
+
+		return elems[i].key.window.String() < elems[j].key.window.String()
+	})
+
+	for _, c := range elems {
+		c.elem.mu.Lock()
+	}
+
+	defer func() {
+		for i := len(elems) - 1; i >= 0; i-- {
+			elems[i].elem.mu.Unlock()
+		}
+	}()
+
+	values := make([]database.ValueType, len(keys))
+	allowed := true
+	for _, c := range elems {
+		values[c.idx] = c.elem.valueAtLocked(txCtx.CurrTime, c.mode)
+		if values[c.idx] >= c.limit {
+			allowed = false
+		}
+	}
+
+	if allowed {
+		for _, c := range elems {
+			values[c.idx] = c.elem.incrLocked(txCtx, 1, c.mode)
+			if txCtx.DryRun {
+				continue
+			}
+
+			e.partitions[c.partition].notify(c.key)
+			e.subscriptions.notify(c.batchKey, values[c.idx]-1, values[c.idx])
+		}
+	}
+
+	if e.logger.GetLevel() == zerolog.DebugLevel {
+		e.logger.Debug().
+			Any("tx_ctx", txCtx).
+			Any("keys", keys).
+			Any("limits", limits).
+			Any("values", values).
+			Bool("allowed", allowed).
+			Msg("success mcapincr query")
+	}
+
+	return values, allowed
+}
+
+// Throttle applies the GCRA rate limit of the key at the moment now (unix nanoseconds).
+func (e *Engine) Throttle(
+	txCtx database.TxContext,
+	key database.ThrottleKey,
+	now int64,
+) database.ThrottleResult {
+	idx := e.partitionIdx(key.Key)
+	partition := e.partitions[idx]
+	res := partition.Throttle(txCtx, key, now)
+
+	if e.logger.GetLevel() == zerolog.DebugLevel {
+		e.logger.Debug().
+			Any("tx_ctx", txCtx).
+			Any("key", key).
+			Int64("now", now).
+			Any("result", res).
+			Msg("success throttle query")
+	}
+
+	return res
+}
+
 func (e *Engine) Get(key database.BatchKey) (database.ValueType, bool) {
 	idx := e.partitionIdx(key.Key)
 	partition := e.partitions[idx]
@@ -126,14 +329,98 @@ func (e *Engine) Get(key database.BatchKey) (database.ValueType, bool) {
 	return value, found
 }
 
+func (e *Engine) History(key database.BatchKey, n int) ([]database.HistoryEntry, error) {
+	idx := e.partitionIdx(key.Key)
+	partition := e.partitions[idx]
+	entries, err := partition.History(key, n)
+
+	if e.logger.GetLevel() == zerolog.DebugLevel {
+		e.logger.Debug().
+			Any("key", key).
+			Int("n", n).
+			Any("entries", entries).
+			Err(err).
+			Msg("success history query")
+	}
+
+	return entries, err
+}
+
+// SetCommands sets the registry whose commands replay WAL records, replays of builtin commands are set in it.
+// It must be called before WAL records are applied.
+func (e *Engine) SetCommands(commands commandRegistry) error {
+	if err := e.setBuiltinReplays(commands); err != nil {
+		return err
+	}
+
+	e.commands = commands
+
+	return nil
+}
+
+// SetStaticPolicies sets policies defined in the config.
+func (e *Engine) SetStaticPolicies(policies []database.Policy) {
+	e.policies.setStatic(policies)
+}
+
+func (e *Engine) SetPolicy(policy database.Policy) error {
+	err := e.policies.set(policy)
+
+	if e.logger.GetLevel() == zerolog.DebugLevel {
+		e.logger.Debug().
+			Any("policy", policy).
+			Err(err).
+			Msg("success policy set query")
+	}
+
+	return err
+}
+
+func (e *Engine) GetPolicy(name string) (database.Policy, bool) {
+	return e.policies.get(name)
+}
+
+func (e *Engine) DelPolicy(name string) (bool, error) {
+	res, err := e.policies.del(name)
+
+	if e.logger.GetLevel() == zerolog.DebugLevel {
+		e.logger.Debug().
+			Str("name", name).
+			Bool("result", res).
+			Err(err).
+			Msg("success policy del query")
+	}
+
+	return res, err
+}
+
+// Watch registers a watcher of the key, see HashTable.Watch.
+func (e *Engine) Watch(key database.BatchKey) (<-chan struct{}, func()) {
+	idx := e.partitionIdx(key.Key)
+	partition := e.partitions[idx]
+
+	return partition.Watch(key)
+}
+
+// SubscribeThreshold subscribes to writes making counters of keys matching the pattern reach the limit
+// in the window. Writes replayed from the WAL are reported as well, so subscriptions work on slaves.
+// cancel must be called when the subscription is not needed anymore.
+func (e *Engine) SubscribeThreshold(
+	pattern string,
+	window database.Window,
+	limit database.ValueType,
+) (events <-chan database.ThresholdEvent, cancel func(), err error) {
+	return e.subscriptions.subscribe(pattern, window, limit)
+}
+
 func (e *Engine) Del(txCtx database.TxContext, key database.BatchKey) bool {
-	if txCtx.FromWAL && isExpired(txCtx.CurrTime, database.TxTime(key.BatchSize)) {
+	if txCtx.FromWAL && isExpired(txCtx.CurrTime, key.Window) {
 		return false
 	}
 
 	idx := e.partitionIdx(key.Key)
 	partition := e.partitions[idx]
-	res := partition.Del(key)
+	res := partition.Del(txCtx, key)
 
 	if e.logger.GetLevel() == zerolog.DebugLevel {
 		e.logger.Debug().
@@ -145,7 +432,25 @@ func (e *Engine) Del(txCtx database.TxContext, key database.BatchKey) bool {
 	return res
 }
 
+// MIncr increments counters of several keys, it isn't atomic across the keys.
+func (e *Engine) MIncr(txCtx database.TxContext, keys []database.BatchKey) []database.ValueType {
+	if txCtx.DryRun {
+		return e.dryRunMIncr(txCtx, keys)
+	}
+
+	res := make([]database.ValueType, len(keys))
+	for i, k := range keys {
+		res[i] = e.Incr(txCtx, k)
+	}
+
+	return res
+}
+
 func (e *Engine) MDel(txCtx database.TxContext, keys []database.BatchKey) []bool {
+	if txCtx.DryRun {
+		return e.dryRunMDel(txCtx, keys)
+	}
+
 	res := make([]bool, len(keys))
 	for i, k := range keys {
 		v := e.Del(txCtx, k)
@@ -155,12 +460,57 @@ func (e *Engine) MDel(txCtx database.TxContext, keys []database.BatchKey) []bool
 	return res
 }
 
+// dryRunMIncr computes results of MIncr on copies of the elements, a repeated key is incremented on the same copy.
+func (e *Engine) dryRunMIncr(txCtx database.TxContext, keys []database.BatchKey) []database.ValueType {
+	elems := make(map[hashTableKey]*FqElem, len(keys))
+	res := make([]database.ValueType, len(keys))
+	for i, key := range keys {
+		htKey := hashTableKey{key: key.Key, window: key.Window}
+		elem, ok := elems[htKey]
+		if !ok {
+			elem = e.partitions[e.partitionIdx(key.Key)].writeElem(txCtx, htKey)
+			elems[htKey] = elem
+		}
+
+		res[i] = elem.Incr(txCtx, key.Mode)
+	}
+
+	return res
+}
+
+// dryRunMDel computes results of MDel, a repeated key is deleted only by its first occurrence.
+func (e *Engine) dryRunMDel(txCtx database.TxContext, keys []database.BatchKey) []bool {
+	deleted := make(map[hashTableKey]struct{}, len(keys))
+	res := make([]bool, len(keys))
+	for i, key := range keys {
+		htKey := hashTableKey{key: key.Key, window: key.Window}
+		if _, ok := deleted[htKey]; ok {
+			continue
+		}
+
+		res[i] = e.Del(txCtx, key)
+		deleted[htKey] = struct{}{}
+	}
+
+	return res
+}
+
 func (e *Engine) Clean(ctx context.Context) {
 	for _, partition := range e.partitions {
 		partition.Clean(ctx)
 	}
 }
 
+// Reset removes keys and dynamic policies, e.g. before the state is replaced by the dump of a master.
+// Static policies and subscriptions are kept.
+func (e *Engine) Reset() {
+	for _, partition := range e.partitions {
+		partition.Reset()
+	}
+
+	e.policies.resetDynamic()
+}
+
 func (e *Engine) Dump(ctx context.Context, dumpTx database.Tx) (resC <-chan database.DumpElem, errsC <-chan error) {
 	ch := make(chan database.DumpElem, 1)
 	errC := make(chan error, 1)
@@ -172,23 +522,75 @@ func (e *Engine) Dump(ctx context.Context, dumpTx database.Tx) (resC <-chan data
 		for _, partition := range e.partitions {
 			partition.Dump(ctx, dumpTx, ch)
 		}
+
+		// the current state of policies is dumped, later changes are replayed from the WAL anyway
+		for _, policy := range e.policies.dynamic() {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- database.DumpElem{
+				Kind:   database.DumpElemPolicy,
+				Key:    policy.Name,
+				Window: policy.Window.String(),
+				Value:  policy.Limit,
+				Mode:   policy.Mode,
+				Tx:     dumpTx,
+			}:
+			}
+		}
 	}()
 
 	return ch, errC
 }
 
 func (e *Engine) RestoreDumpElem(_ context.Context, elem database.DumpElem) error {
-	if isExpired(elem.TxAt, database.TxTime(elem.BatchSize)) {
-		return nil
+	if elem.Kind == database.DumpElemPolicy {
+		return e.restorePolicy(elem)
+	}
+
+	var window database.Window
+	if elem.Kind == database.DumpElemThrottle {
+		if elem.TAT < time.Now().UnixNano() {
+			return nil
+		}
+	} else {
+		var err error
+		if window, err = dumpElemWindow(elem); err != nil {
+			return err
+		}
+
+		if isExpired(elem.TxAt, window) {
+			return nil
+		}
 	}
 
 	idx := e.partitionIdx(elem.Key)
 	partition := e.partitions[idx]
-	partition.RestoreDumpElem(elem)
+	partition.RestoreDumpElem(elem, window)
 
 	return nil
 }
 
+func (e *Engine) restorePolicy(elem database.DumpElem) error {
+	window, err := database.ParseWindow(elem.Window)
+	if err != nil {
+		return fmt.Errorf("dump policy %s: %w", elem.Key, err)
+	}
+
+	err = e.policies.set(database.Policy{
+		Name:   elem.Key,
+		Window: window,
+		Limit:  elem.Value,
+		Mode:   elem.Mode,
+	})
+	if errors.Is(err, database.ErrPolicyReadOnly) {
+		// the policy is defined in the config now
+		return nil
+	}
+
+	return err
+}
+
 func (e *Engine) partitionIdx(key string) int {
 	// Fast hash function for partition selection
 	// Using simple multiplicative hash for better performance
@@ -199,17 +601,59 @@ func (e *Engine) partitionIdx(key string) int {
 	return int(hash) % len(e.partitions)
 }
 
-//nolint:gocritic
+// setBuiltinReplays sets the replays of builtin commands in the registry.
+func (e *Engine) setBuiltinReplays(commands commandRegistry) error {
+	for id, apply := range e.builtinReplays() {
+		if err := commands.SetReplay(id, replayOf(apply)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Engine) builtinReplays() map[compute.CommandID]func(*wal.LogData) {
+	return map[compute.CommandID]func(*wal.LogData){
+		compute.IncrCommandID:     e.applyIncrFromLog,
+		compute.DelCommandID:      e.applyDelFromLog,
+		compute.MDelCommandID:     e.applyMDelFromLog,
+		compute.MIncrCommandID:    e.applyMIncrFromLog,
+		compute.IncrByCommandID:   e.applyIncrByFromLog,
+		compute.CapIncrCommandID:  e.applyCapIncrFromLog,
+		compute.MCapIncrCommandID: e.applyMCapIncrFromLog,
+		compute.ThrottleCommandID: e.applyThrottleFromLog,
+		compute.PolicyCommandID:   e.applyPolicyFromLog,
+	}
+}
+
+// replayOf adapts a replay of a builtin command to the registry, builtin replays log their errors themselves.
+func replayOf(apply func(*wal.LogData)) compute.ReplayFunc {
+	return func(lsn uint64, args []string, results []int64) error {
+		apply(&wal.LogData{LSN: lsn, Arguments: args, Results: results})
+
+		return nil
+	}
+}
+
 func (e *Engine) applyLogs(logs []*wal.LogData) {
 	for _, log := range logs {
-		switch compute.CommandID(log.CommandId) {
-		case compute.IncrCommandID:
-			e.applyIncrFromLog(log)
-		case compute.DelCommandID:
-			e.applyDelFromLog(log)
-		case compute.MDelCommandID:
-			e.applyMDelFromLog(log)
-		}
+		e.applyLog(log)
+	}
+}
+
+// applyLog replays a WAL record by the command of the registry.
+func (e *Engine) applyLog(log *wal.LogData) {
+	command, found := e.commands.CommandByID(compute.CommandID(log.CommandId))
+	if !found || command.Replay == nil {
+		e.logger.Warn().
+			Uint64("lsn", log.LSN).
+			Uint32("command_id", log.CommandId).
+			Msg("WAL log of a command without replay is skipped")
+		return
+	}
+
+	if err := command.Replay(log.LSN, log.Arguments, log.Results); err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to apply WAL log for " + command.Name)
 	}
 }
 
@@ -228,9 +672,73 @@ func (e *Engine) applyIncrFromLog(log *wal.LogData) {
 		return
 	}
 
+	if batchKey.Mode, err = parseWALWindowMode(log.Arguments, 3); err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to parse WAL log window mode for INCR")
+		return
+	}
+
 	e.Incr(txCtx, batchKey)
 }
 
+func (e *Engine) applyIncrByFromLog(log *wal.LogData) {
+	if len(log.Arguments) < 4 {
+		e.logger.Error().
+			Uint64("lsn", log.LSN).
+			Int("arguments_count", len(log.Arguments)).
+			Msg("invalid WAL log: insufficient arguments for INCRBY")
+		return
+	}
+
+	batchKey, txCtx, err := parseWALBatchKeyAndCtx(log.LSN, log.Arguments[0], log.Arguments[1], log.Arguments[2])
+	if err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to parse WAL log for INCRBY")
+		return
+	}
+
+	delta, err := strconv.ParseInt(log.Arguments[3], 10, 64)
+	if err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to parse WAL log delta for INCRBY")
+		return
+	}
+
+	if batchKey.Mode, err = parseWALWindowMode(log.Arguments, 4); err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to parse WAL log window mode for INCRBY")
+		return
+	}
+
+	// overflow was rejected for the original query as well, so the error is ignored here
+	_, _ = e.IncrBy(txCtx, batchKey, database.ValueType(delta))
+}
+
+func (e *Engine) applyCapIncrFromLog(log *wal.LogData) {
+	if len(log.Arguments) < 4 {
+		e.logger.Error().
+			Uint64("lsn", log.LSN).
+			Int("arguments_count", len(log.Arguments)).
+			Msg("invalid WAL log: insufficient arguments for CAPINCR")
+		return
+	}
+
+	batchKey, txCtx, err := parseWALBatchKeyAndCtx(log.LSN, log.Arguments[0], log.Arguments[1], log.Arguments[2])
+	if err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to parse WAL log for CAPINCR")
+		return
+	}
+
+	limit, err := strconv.ParseInt(log.Arguments[3], 10, 64)
+	if err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to parse WAL log limit for CAPINCR")
+		return
+	}
+
+	if batchKey.Mode, err = parseWALWindowMode(log.Arguments, 4); err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to parse WAL log window mode for CAPINCR")
+		return
+	}
+
+	e.CapIncr(txCtx, batchKey, database.ValueType(limit))
+}
+
 func (e *Engine) applyDelFromLog(log *wal.LogData) {
 	if len(log.Arguments) < 3 {
 		e.logger.Error().
@@ -250,12 +758,22 @@ func (e *Engine) applyDelFromLog(log *wal.LogData) {
 }
 
 func (e *Engine) applyMDelFromLog(log *wal.LogData) {
+	batchKeys, txCtx, ok := e.parseWALBatchKeys(log, compute.MDelCommand)
+	if !ok {
+		return
+	}
+
+	e.MDel(txCtx, batchKeys)
+}
+
+// parseWALBatchKeys parses log arguments of multi-key commands: current time and pairs of key and capping.
+func (e *Engine) parseWALBatchKeys(log *wal.LogData, command string) ([]database.BatchKey, database.TxContext, bool) {
 	if len(log.Arguments) < 1 || (len(log.Arguments)-1)%2 != 0 {
 		e.logger.Error().
 			Uint64("lsn", log.LSN).
 			Int("arguments_count", len(log.Arguments)).
-			Msg("invalid WAL log: insufficient or invalid arguments for MDEL")
-		return
+			Msg("invalid WAL log: insufficient or invalid arguments for " + command)
+		return nil, database.TxContext{}, false
 	}
 
 	var txCtx database.TxContext
@@ -266,15 +784,136 @@ func (e *Engine) applyMDelFromLog(log *wal.LogData) {
 	for i := 1; i < len(log.Arguments); i += 2 {
 		batchKey, parsedTxCtx, err := parseWALBatchKeyAndCtx(log.LSN, log.Arguments[i], log.Arguments[i+1], currTimeStr)
 		if err != nil {
-			e.logger.Error().Err(err).Uint64("lsn", log.LSN).Int("arg_index", i).Msg("failed to parse WAL log argument for MDEL")
+			e.logger.Error().Err(err).Uint64("lsn", log.LSN).Int("arg_index", i).Msg("failed to parse WAL log argument for " + command)
 			continue
 		}
 		txCtx = parsedTxCtx
 		batchKeys = append(batchKeys, batchKey)
 	}
 
-	if len(batchKeys) > 0 {
-		e.MDel(txCtx, batchKeys)
+	return batchKeys, txCtx, len(batchKeys) > 0
+}
+
+func (e *Engine) applyMIncrFromLog(log *wal.LogData) {
+	batchKeys, txCtx, ok := e.parseWALBatchKeys(log, compute.MIncrCommand)
+	if !ok {
+		return
+	}
+
+	e.MIncr(txCtx, batchKeys)
+}
+
+func (e *Engine) applyMCapIncrFromLog(log *wal.LogData) {
+	if len(log.Arguments) < 4 || (len(log.Arguments)-1)%3 > 1 {
+		e.logger.Error().
+			Uint64("lsn", log.LSN).
+			Int("arguments_count", len(log.Arguments)).
+			Msg("invalid WAL log: insufficient or invalid arguments for MCAPINCR")
+		return
+	}
+
+	var txCtx database.TxContext
+	currTimeStr := log.Arguments[0]
+	expectedKeys := (len(log.Arguments) - 1) / 3
+	lastKeyArgIdx := expectedKeys * 3
+
+	mode, err := parseWALWindowMode(log.Arguments, lastKeyArgIdx+1)
+	if err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to parse WAL log window mode for MCAPINCR")
+		return
+	}
+
+	batchKeys := make([]database.BatchKey, 0, expectedKeys)
+	limits := make([]database.ValueType, 0, expectedKeys)
+	for i := 1; i < lastKeyArgIdx; i += 3 {
+		batchKey, parsedTxCtx, err := parseWALBatchKeyAndCtx(log.LSN, log.Arguments[i], log.Arguments[i+1], currTimeStr)
+		if err != nil {
+			e.logger.Error().Err(err).Uint64("lsn", log.LSN).Int("arg_index", i).Msg("failed to parse WAL log argument for MCAPINCR")
+			return
+		}
+
+		limit, err := strconv.ParseInt(log.Arguments[i+2], 10, 64)
+		if err != nil {
+			e.logger.Error().Err(err).Uint64("lsn", log.LSN).Int("arg_index", i+2).Msg("failed to parse WAL log limit for MCAPINCR")
+			return
+		}
+
+		batchKey.Mode = mode
+		txCtx = parsedTxCtx
+		batchKeys = append(batchKeys, batchKey)
+		limits = append(limits, database.ValueType(limit))
+	}
+
+	// the logged decision is applied as is: windows which expired since then
+	// can't be checked again, and the decision mustn't be made by the rest of them
+	if len(log.Results) == len(batchKeys)+1 {
+		if log.Results[len(batchKeys)] != 0 {
+			e.MIncr(txCtx, batchKeys)
+		}
+
+		return
+	}
+
+	e.MCapIncr(txCtx, batchKeys, limits)
+}
+
+func (e *Engine) applyThrottleFromLog(log *wal.LogData) {
+	if len(log.Arguments) < 6 {
+		e.logger.Error().
+			Uint64("lsn", log.LSN).
+			Int("arguments_count", len(log.Arguments)).
+			Msg("invalid WAL log: insufficient arguments for THROTTLE")
+		return
+	}
+
+	key, now, err := parseWALThrottleKey(log.Arguments)
+	if err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to parse WAL log for THROTTLE")
+		return
+	}
+
+	txCtx := database.TxContext{
+		Tx:       database.Tx(log.LSN),
+		CurrTime: database.TxTime(now / int64(time.Second)),
+		FromWAL:  true,
+	}
+
+	// the logged decision is applied as is, so replicas keep the GCRA state of the master
+	if len(log.Results) == 2 {
+		if log.Results[0] != 0 {
+			e.partitions[e.partitionIdx(key.Key)].SetThrottle(txCtx, key.Key, log.Results[1])
+		}
+
+		return
+	}
+
+	e.Throttle(txCtx, key, now)
+}
+
+func (e *Engine) applyPolicyFromLog(log *wal.LogData) {
+	if len(log.Arguments) < 2 {
+		e.logger.Error().
+			Uint64("lsn", log.LSN).
+			Int("arguments_count", len(log.Arguments)).
+			Msg("invalid WAL log: insufficient arguments for POLICY")
+		return
+	}
+
+	var err error
+	switch log.Arguments[0] {
+	case compute.PolicySetSubcommand:
+		var policy database.Policy
+		if policy, err = parseWALPolicy(log.Arguments[1:]); err == nil {
+			err = e.SetPolicy(policy)
+		}
+	case compute.PolicyDelSubcommand:
+		_, err = e.DelPolicy(log.Arguments[1])
+	default:
+		err = ErrInvalidWALData
+	}
+
+	if err != nil {
+		e.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to apply WAL log for POLICY")
 	}
 }
 
@@ -287,10 +926,29 @@ func (e *Engine) applyDump(dumpElems []database.DumpElem) {
 	}
 }
 
+// dumpElemWindow returns the window of a counter dump element.
+// Dumps written before calendar windows carry the batch size only.
+func dumpElemWindow(elem database.DumpElem) (database.Window, error) {
+	if elem.Window == "" {
+		if elem.BatchSize == 0 {
+			return database.Window{}, fmt.Errorf("dump elem %s: %w", elem.Key, database.ErrInvalidWindow)
+		}
+
+		return database.NewSecondsWindow(elem.BatchSize), nil
+	}
+
+	window, err := database.ParseWindow(elem.Window)
+	if err != nil {
+		return database.Window{}, fmt.Errorf("dump elem %s: %w", elem.Key, err)
+	}
+
+	return window, nil
+}
+
 func parseWALBatchKeyAndCtx(lsn uint64, key, batchSizeStr, currTimeStr string) (database.BatchKey, database.TxContext, error) {
-	batchSize, err := strconv.ParseUint(batchSizeStr, 10, 32)
+	window, err := database.ParseWindow(batchSizeStr)
 	if err != nil {
-		return database.BatchKey{}, database.TxContext{}, fmt.Errorf("WAL log: parse batch size: %w", err)
+		return database.BatchKey{}, database.TxContext{}, fmt.Errorf("WAL log: parse window: %w", err)
 	}
 
 	currTime, err := strconv.ParseInt(currTimeStr, 16, 64)
@@ -299,7 +957,7 @@ func parseWALBatchKeyAndCtx(lsn uint64, key, batchSizeStr, currTimeStr string) (
 	}
 
 	batchKey := database.BatchKey{
-		BatchSize:    uint32(batchSize),
+		Window:       window,
 		BatchSizeStr: batchSizeStr,
 		Key:          key,
 	}
@@ -313,18 +971,104 @@ func parseWALBatchKeyAndCtx(lsn uint64, key, batchSizeStr, currTimeStr string) (
 	return batchKey, txCtx, nil
 }
 
-func isExpired(currTime, batchSize database.TxTime) bool {
-	return database.TxTime(time.Now().Unix()) > endOfBatch(currTime, batchSize)
+// parseWALThrottleKey parses THROTTLE log arguments: key, rate, period, burst, quantity, now.
+func parseWALThrottleKey(args []string) (database.ThrottleKey, int64, error) {
+	key := database.ThrottleKey{
+		Key:       args[0],
+		PeriodStr: args[2],
+	}
+
+	rate, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return database.ThrottleKey{}, 0, fmt.Errorf("WAL log: parse rate: %w", err)
+	}
+
+	period, err := strconv.ParseUint(args[2], 10, 32)
+	if err != nil {
+		return database.ThrottleKey{}, 0, fmt.Errorf("WAL log: parse period: %w", err)
+	}
+
+	burst, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return database.ThrottleKey{}, 0, fmt.Errorf("WAL log: parse burst: %w", err)
+	}
+
+	quantity, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		return database.ThrottleKey{}, 0, fmt.Errorf("WAL log: parse quantity: %w", err)
+	}
+
+	now, err := strconv.ParseInt(args[5], 16, 64)
+	if err != nil {
+		return database.ThrottleKey{}, 0, fmt.Errorf("WAL log: parse curr time: %w", err)
+	}
+
+	key.Rate = rate
+	key.Period = uint32(period)
+	key.Burst = burst
+	key.Quantity = quantity
+
+	if err := key.Validate(); err != nil {
+		return database.ThrottleKey{}, 0, fmt.Errorf("WAL log: %w", err)
+	}
+
+	return key, now, nil
+}
+
+// parseWALPolicy parses POLICY SET log arguments: name, window, limit and optional window mode.
+func parseWALPolicy(args []string) (database.Policy, error) {
+	if len(args) < 3 {
+		return database.Policy{}, ErrInvalidWALData
+	}
+
+	window, err := database.ParseWindow(args[1])
+	if err != nil {
+		return database.Policy{}, fmt.Errorf("WAL log: parse window: %w", err)
+	}
+
+	limit, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return database.Policy{}, fmt.Errorf("WAL log: parse limit: %w", err)
+	}
+
+	mode, err := parseWALWindowMode(args, 3)
+	if err != nil {
+		return database.Policy{}, err
+	}
+
+	return database.Policy{
+		Name:   args[0],
+		Window: window,
+		Limit:  database.ValueType(limit),
+		Mode:   mode,
+	}, nil
+}
+
+// parseWALWindowMode parses an optional window mode argument of a WAL log.
+func parseWALWindowMode(args []string, idx int) (database.WindowMode, error) {
+	if len(args) <= idx {
+		return database.WindowModeDefault, nil
+	}
+
+	mode, err := database.ParseWindowMode(args[idx])
+	if err != nil {
+		return database.WindowModeDefault, fmt.Errorf("WAL log: parse window mode: %w", err)
+	}
+
+	return mode, nil
 }
 
-func isExpiredWithDelta(currTime, batchSize database.TxTime) bool {
-	return database.TxTime(time.Now().Unix()) > (endOfBatch(currTime, batchSize) + expireDelta)
+// isExpired reports whether the batch of currTime and the batch following it are over.
+// Elements are retained for one more batch because sliding windows use the previous batch value.
+func isExpired(currTime database.TxTime, window database.Window) bool {
+	return time.Now().Unix() >= retainedUntil(currTime, window)
 }
 
-func startOfBatch(currTime, batchSize database.TxTime) database.TxTime {
-	return currTime / batchSize * batchSize
+func isExpiredWithDelta(currTime database.TxTime, window database.Window) bool {
+	return time.Now().Unix() >= retainedUntil(currTime, window)+int64(expireDelta)
 }
 
-func endOfBatch(currTime, batchSize database.TxTime) database.TxTime {
-	return startOfBatch(currTime, batchSize) + batchSize - 1
+// retainedUntil returns the unix time when the batch following the batch of currTime is over.
+func retainedUntil(currTime database.TxTime, window database.Window) int64 {
+	return window.End(window.End(int64(currTime)))
 }
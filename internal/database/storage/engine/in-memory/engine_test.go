@@ -0,0 +1,381 @@
+package inmemory
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+	"fq/internal/database/compute"
+	"fq/internal/database/storage/wal"
+)
+
+func TestEngine_MCapIncr(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	keys := []database.BatchKey{
+		{Key: "user", Window: database.NewSecondsWindow(3600), BatchSizeStr: "3600"},
+		{Key: "user", Window: database.NewSecondsWindow(86400), BatchSizeStr: "86400"},
+	}
+	limits := []database.ValueType{1, 2}
+	txCtx := database.TxContext{Tx: 1, CurrTime: database.TxTime(time.Now().Unix())}
+
+	values, allowed := engine.MCapIncr(txCtx, keys, limits)
+	require.True(t, allowed)
+	require.Equal(t, []database.ValueType{1, 1}, values)
+
+	txCtx.Tx++
+	values, allowed = engine.MCapIncr(txCtx, keys, limits)
+	require.False(t, allowed)
+	require.Equal(t, []database.ValueType{1, 1}, values)
+
+	value, _ := engine.Get(keys[1])
+	require.Equal(t, database.ValueType(1), value)
+}
+
+func TestEngine_MCapIncrReplay(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	now := time.Now().Unix()
+	minute := database.BatchKey{Key: "user", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"}
+	hour := database.BatchKey{Key: "user", Window: database.NewSecondsWindow(3600), BatchSizeStr: "3600"}
+
+	// the minute window was at its limit on the master, but it has expired by the replay
+	engine.applyLogs([]*wal.LogData{{
+		LSN:       1,
+		CommandId: uint32(compute.MCapIncrCommandID),
+		Arguments: []string{strconv.FormatInt(now-120, 16), "user", "60", "1", "user", "3600", "10"},
+		Results:   []int64{1, 1, 0},
+	}})
+
+	_, ok := engine.Get(hour)
+	require.False(t, ok)
+
+	// an allowed request is applied even if the limit is reached on replay
+	for lsn := uint64(2); lsn <= 3; lsn++ {
+		engine.applyLogs([]*wal.LogData{{
+			LSN:       lsn,
+			CommandId: uint32(compute.MCapIncrCommandID),
+			Arguments: []string{strconv.FormatInt(now, 16), "user", "60", "1", "user", "3600", "10"},
+			Results:   []int64{1, 1, 1},
+		}})
+	}
+
+	value, _ := engine.Get(minute)
+	require.Equal(t, database.ValueType(2), value)
+
+	value, _ = engine.Get(hour)
+	require.Equal(t, database.ValueType(2), value)
+}
+
+func TestEngine_MIncr(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	keys := []database.BatchKey{
+		{Key: "user:1", Window: database.NewSecondsWindow(3600), BatchSizeStr: "3600"},
+		{Key: "user:2", Window: database.NewSecondsWindow(3600), BatchSizeStr: "3600"},
+	}
+	now := time.Now().Unix()
+	txCtx := database.TxContext{Tx: 1, CurrTime: database.TxTime(now)}
+
+	values := engine.MIncr(txCtx, keys)
+	require.Equal(t, []database.ValueType{1, 1}, values)
+
+	// a replayed MINCR record increments every key of the batch
+	engine.applyLogs([]*wal.LogData{{
+		LSN:       2,
+		CommandId: uint32(compute.MIncrCommandID),
+		Arguments: []string{strconv.FormatInt(now, 16), "user:1", "3600", "user:2", "3600"},
+	}})
+
+	for _, key := range keys {
+		value, _ := engine.Get(key)
+		require.Equal(t, database.ValueType(2), value)
+	}
+}
+
+func TestEngine_DryRun(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	key := database.BatchKey{Key: "user:1", Window: database.NewSecondsWindow(3600), BatchSizeStr: "3600"}
+	other := database.BatchKey{Key: "user:2", Window: database.NewSecondsWindow(3600), BatchSizeStr: "3600"}
+	txCtx := database.TxContext{Tx: 1, CurrTime: database.TxTime(time.Now().Unix())}
+	dryCtx := txCtx
+	dryCtx.DryRun = true
+
+	require.Equal(t, database.ValueType(1), engine.Incr(dryCtx, key))
+	require.Equal(t, []database.ValueType{1, 2, 1}, engine.MIncr(dryCtx, []database.BatchKey{key, key, other}))
+
+	_, ok := engine.Get(key)
+	require.False(t, ok)
+
+	require.Equal(t, []database.ValueType{1, 2, 1}, engine.MIncr(txCtx, []database.BatchKey{key, key, other}))
+
+	txCtx.Tx++
+	dryCtx.Tx++
+	value, allowed := engine.CapIncr(dryCtx, key, 3)
+	require.True(t, allowed)
+	require.Equal(t, database.ValueType(3), value)
+	require.Equal(t, []bool{true, false}, engine.MDel(dryCtx, []database.BatchKey{key, key}))
+
+	value, _ = engine.Get(key)
+	require.Equal(t, database.ValueType(2), value)
+	require.Equal(t, []bool{true, false}, engine.MDel(txCtx, []database.BatchKey{key, key}))
+}
+
+func TestEngine_ThrottleReplay(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 1, &logger, nil, nil)
+	require.NoError(t, err)
+
+	key := database.ThrottleKey{Key: "api", Rate: 1, Period: 1, PeriodStr: "1", Burst: 0, Quantity: 1}
+	now := time.Now().UnixNano()
+
+	// a dry run doesn't add the key
+	res := engine.Throttle(database.TxContext{Tx: 1, DryRun: true}, key, now)
+	require.True(t, res.Allowed)
+	require.Empty(t, engine.partitions[0].(*HashTable).throttles)
+
+	throttleLog := func(lsn uint64, allowed, tat int64) *wal.LogData {
+		return &wal.LogData{
+			LSN:       lsn,
+			CommandId: uint32(compute.ThrottleCommandID),
+			Arguments: []string{"api", "1", "1", "0", "1", strconv.FormatInt(now, 16)},
+			Results:   []int64{allowed, tat},
+		}
+	}
+
+	// the logged decisions are applied regardless of the state of the replica
+	engine.applyLogs([]*wal.LogData{throttleLog(2, 1, now+int64(5*time.Second))})
+	engine.applyLogs([]*wal.LogData{throttleLog(3, 0, now)})
+
+	throttle := engine.partitions[0].(*HashTable).throttles["api"]
+	require.Equal(t, now+int64(5*time.Second), throttle.TAT())
+}
+
+func TestEngine_Policies(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	daily, err := database.ParseWindow("1d")
+	require.NoError(t, err)
+
+	engine.SetStaticPolicies([]database.Policy{{Name: "static", Window: daily, Limit: 5}})
+
+	policy, ok := engine.GetPolicy("static")
+	require.True(t, ok)
+	require.True(t, policy.Static)
+
+	require.ErrorIs(t, engine.SetPolicy(database.Policy{Name: "static", Window: daily, Limit: 1}), database.ErrPolicyReadOnly)
+	_, err = engine.DelPolicy("static")
+	require.ErrorIs(t, err, database.ErrPolicyReadOnly)
+
+	engine.applyLogs([]*wal.LogData{
+		{LSN: 1, CommandId: uint32(compute.PolicyCommandID), Arguments: []string{"SET", "hourly", "3600", "3", "SLIDING"}},
+		{LSN: 2, CommandId: uint32(compute.PolicyCommandID), Arguments: []string{"SET", "weekly", "1w", "30"}},
+		{LSN: 3, CommandId: uint32(compute.PolicyCommandID), Arguments: []string{"DEL", "weekly"}},
+	})
+
+	policy, ok = engine.GetPolicy("hourly")
+	require.True(t, ok)
+	require.Equal(t, database.Policy{
+		Name:   "hourly",
+		Window: database.NewSecondsWindow(3600),
+		Limit:  3,
+		Mode:   database.WindowModeSliding,
+	}, policy)
+
+	_, ok = engine.GetPolicy("weekly")
+	require.False(t, ok)
+
+	// only dynamic policies are dumped
+	elems, _ := engine.Dump(context.Background(), 3)
+	var dumped []database.DumpElem
+	for elem := range elems {
+		dumped = append(dumped, elem)
+	}
+
+	require.Len(t, dumped, 1)
+	require.Equal(t, database.DumpElemPolicy, dumped[0].Kind)
+
+	restored, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, restored.RestoreDumpElem(context.Background(), dumped[0]))
+
+	policy, ok = restored.GetPolicy("hourly")
+	require.True(t, ok)
+	require.Equal(t, database.ValueType(3), policy.Limit)
+	require.Equal(t, database.WindowModeSliding, policy.Mode)
+}
+
+func TestEngine_Reset(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	engine.SetStaticPolicies([]database.Policy{{Name: "static", Window: database.NewSecondsWindow(60), Limit: 5}})
+	require.NoError(t, engine.SetPolicy(database.Policy{Name: "dynamic", Window: database.NewSecondsWindow(60), Limit: 3}))
+
+	key := database.BatchKey{Key: "user", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"}
+	txCtx := database.TxContext{Tx: 1, CurrTime: database.TxTime(time.Now().Unix())}
+	engine.Incr(txCtx, key)
+
+	changed, cancel := engine.Watch(key)
+	defer cancel()
+
+	engine.Reset()
+
+	_, ok := engine.Get(key)
+	require.False(t, ok)
+
+	_, ok = engine.GetPolicy("dynamic")
+	require.False(t, ok)
+	_, ok = engine.GetPolicy("static")
+	require.True(t, ok)
+
+	// watchers are woken, as values of their keys are removed
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("watcher is not woken")
+	}
+
+	require.Equal(t, database.ValueType(1), engine.Incr(database.TxContext{Tx: 2, CurrTime: txCtx.CurrTime}, key))
+}
+
+func TestEngine_Watch(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	key := database.BatchKey{Key: "user", Window: database.NewSecondsWindow(3600), BatchSizeStr: "3600"}
+	other := database.BatchKey{Key: "user", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"}
+	now := time.Now().Unix()
+
+	changed, cancel := engine.Watch(key)
+
+	engine.Incr(database.TxContext{Tx: 1, CurrTime: database.TxTime(now)}, other)
+	select {
+	case <-changed:
+		t.Fatal("watcher of another key is woken")
+	default:
+	}
+
+	// changes replicated through the WAL wake watchers as well
+	engine.applyLogs([]*wal.LogData{{
+		LSN:       2,
+		CommandId: uint32(compute.IncrCommandID),
+		Arguments: []string{"user", "3600", strconv.FormatInt(now, 16)},
+	}})
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("watcher is not woken")
+	}
+	cancel()
+
+	// canceled watchers are removed
+	_, cancel = engine.Watch(key)
+	cancel()
+	cancel()
+
+	partition := engine.partitions[engine.partitionIdx(key.Key)].(*HashTable)
+	require.Empty(t, partition.watchers)
+	require.Equal(t, int64(0), partition.watching.Load())
+}
+
+func TestEngine_SubscribeThreshold(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.SubscribeThreshold("user:[", database.NewSecondsWindow(3600), 2)
+	require.ErrorIs(t, err, database.ErrInvalidKeyPattern)
+
+	window := database.NewSecondsWindow(3600)
+	events, cancel, err := engine.SubscribeThreshold("user:*", window, 3)
+	require.NoError(t, err)
+
+	now := time.Now().Unix()
+	txCtx := database.TxContext{Tx: 1, CurrTime: database.TxTime(now)}
+	key := database.BatchKey{Key: "user:1", Window: window, BatchSizeStr: "3600"}
+
+	engine.Incr(txCtx, key)
+	engine.Incr(txCtx, database.BatchKey{Key: "campaign:1", Window: window, BatchSizeStr: "3600"})
+	engine.Incr(txCtx, database.BatchKey{Key: "user:1", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"})
+	_, err = engine.IncrBy(txCtx, database.BatchKey{Key: "user:1", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"}, 5)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	// writes replicated through the WAL are reported as well
+	engine.applyLogs([]*wal.LogData{{
+		LSN:       2,
+		CommandId: uint32(compute.IncrByCommandID),
+		Arguments: []string{"user:1", "3600", strconv.FormatInt(now, 16), "4"},
+	}})
+
+	select {
+	case event := <-events:
+		require.Equal(t, database.ThresholdEvent{Key: "user:1", Window: window, Limit: 3, Value: 5}, event)
+	case <-time.After(time.Second):
+		t.Fatal("threshold event is not sent")
+	}
+
+	// the limit is crossed once
+	engine.Incr(txCtx, key)
+	_, allowed := engine.CapIncr(txCtx, key, 10)
+	require.True(t, allowed)
+	require.Empty(t, events)
+
+	cancel()
+	cancel()
+	require.Empty(t, engine.subscriptions.subs)
+	require.Equal(t, int64(0), engine.subscriptions.n.Load())
+}
+
+func TestEngine_ApplyRegisteredCommandLogs(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := NewEngine(HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	var replayed []string
+	commands := compute.NewRegistry()
+	require.NoError(t, commands.Register(compute.Command{
+		ID:    1000,
+		Name:  "NOTE",
+		Class: compute.WriteCommand,
+		Replay: func(lsn uint64, args []string, _ []int64) error {
+			replayed = append(replayed, strconv.FormatUint(lsn, 10)+":"+args[0])
+
+			return nil
+		},
+	}))
+	require.NoError(t, engine.SetCommands(commands))
+
+	engine.applyLogs([]*wal.LogData{
+		{LSN: 1, CommandId: 1000, Arguments: []string{"first"}},
+		{LSN: 2, CommandId: uint32(compute.IncrCommandID), Arguments: []string{"key", "60", strconv.FormatInt(time.Now().Unix(), 16)}},
+		{LSN: 3, CommandId: 1001, Arguments: []string{"unknown"}},
+		{LSN: 4, CommandId: 1000, Arguments: []string{"second"}},
+	})
+
+	require.Equal(t, []string{"1:first", "4:second"}, replayed)
+
+	value, found := engine.Get(database.BatchKey{Key: "key", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"})
+	require.True(t, found)
+	require.Equal(t, database.ValueType(1), value)
+}
@@ -2,40 +2,125 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"fq/internal/database"
 )
 
-var HashTableBuilder = func() hashTable {
-	return NewHashTable()
+var HashTableBuilder = NewHashTableBuilder(0)
+
+// NewHashTableBuilder makes a builder of hash tables keeping historySize closed windows per key.
+func NewHashTableBuilder(historySize int) func() hashTable {
+	return func() hashTable {
+		table := NewHashTable()
+		table.historySize = historySize
+
+		return table
+	}
 }
 
 type hashTableKey struct {
-	key       string
-	batchSize uint32
+	key    string
+	window database.Window
 }
 
 type HashTable struct {
-	mu sync.RWMutex
-	m  map[hashTableKey]*FqElem
+	mu        sync.RWMutex
+	m         map[hashTableKey]*FqElem
+	throttles map[string]*ThrottleElem
+
+	historySize int
+
+	watchMu  sync.Mutex
+	watchers map[hashTableKey]*watchGroup
+	watching atomic.Int64 // number of active watchers, to skip notifications without locking
+}
+
+// watchGroup is a set of watchers of a key, they are woken by closing the channel.
+type watchGroup struct {
+	ch chan struct{}
+	n  int
 }
 
 func NewHashTable() *HashTable {
 	return &HashTable{
-		m: make(map[hashTableKey]*FqElem),
+		m:         make(map[hashTableKey]*FqElem),
+		throttles: make(map[string]*ThrottleElem),
+		watchers:  make(map[hashTableKey]*watchGroup),
 	}
 }
 
 func (s *HashTable) Incr(txCtx database.TxContext, key database.BatchKey) database.ValueType {
-	htKey := hashTableKey{key: key.Key, batchSize: key.BatchSize}
-	v := s.getOrInitElem(htKey)
+	htKey := hashTableKey{key: key.Key, window: key.Window}
+	v := s.writeElem(txCtx, htKey)
+	value := v.Incr(txCtx, key.Mode)
+	if !txCtx.DryRun {
+		s.notify(htKey)
+	}
+
+	return value
+}
+
+func (s *HashTable) IncrBy(
+	txCtx database.TxContext,
+	key database.BatchKey,
+	delta database.ValueType,
+) (database.ValueType, error) {
+	htKey := hashTableKey{key: key.Key, window: key.Window}
+	v := s.writeElem(txCtx, htKey)
+	value, err := v.IncrBy(txCtx, delta, key.Mode)
+	if err == nil && !txCtx.DryRun {
+		s.notify(htKey)
+	}
+
+	return value, err
+}
+
+func (s *HashTable) CapIncr(
+	txCtx database.TxContext,
+	key database.BatchKey,
+	limit database.ValueType,
+) (database.ValueType, bool) {
+	htKey := hashTableKey{key: key.Key, window: key.Window}
+	v := s.writeElem(txCtx, htKey)
+	value, ok := v.CapIncr(txCtx, limit, key.Mode)
+	if ok && !txCtx.DryRun {
+		s.notify(htKey)
+	}
 
-	return v.Incr(txCtx)
+	return value, ok
+}
+
+func (s *HashTable) Throttle(
+	txCtx database.TxContext,
+	key database.ThrottleKey,
+	now int64,
+) database.ThrottleResult {
+	if !txCtx.DryRun {
+		return s.getOrInitThrottle(key.Key).Throttle(txCtx, key, now)
+	}
+
+	// a dry run doesn't change the throttle, a new key isn't added to the table
+	s.mu.RLock()
+	v, ok := s.throttles[key.Key]
+	s.mu.RUnlock()
+
+	if !ok {
+		v = NewThrottleElem()
+	}
+
+	return v.Throttle(txCtx, key, now)
+}
+
+func (s *HashTable) SetThrottle(txCtx database.TxContext, key string, tat int64) {
+	s.getOrInitThrottle(key).SetTAT(txCtx, tat)
 }
 
 func (s *HashTable) Get(key database.BatchKey) (database.ValueType, bool) {
-	htKey := hashTableKey{key: key.Key, batchSize: key.BatchSize}
+	htKey := hashTableKey{key: key.Key, window: key.Window}
 
 	s.mu.RLock()
 	v, ok := s.m[htKey]
@@ -45,11 +130,38 @@ func (s *HashTable) Get(key database.BatchKey) (database.ValueType, bool) {
 		return 0, false
 	}
 
-	return v.Value(), true
+	return v.Value(key.Mode), true
 }
 
-func (s *HashTable) Del(key database.BatchKey) bool {
-	htKey := hashTableKey{key: key.Key, batchSize: key.BatchSize}
+// History returns values of n closed windows of the key preceding the current one.
+func (s *HashTable) History(key database.BatchKey, n int) ([]database.HistoryEntry, error) {
+	if n > s.historySize {
+		return nil, fmt.Errorf("%w: %d (history size is %d)", database.ErrHistoryTooLong, n, s.historySize)
+	}
+
+	htKey := hashTableKey{key: key.Key, window: key.Window}
+
+	s.mu.RLock()
+	v, ok := s.m[htKey]
+	s.mu.RUnlock()
+
+	if !ok {
+		v = NewFqElem(key.Window)
+	}
+
+	return v.History(n), nil
+}
+
+func (s *HashTable) Del(txCtx database.TxContext, key database.BatchKey) bool {
+	htKey := hashTableKey{key: key.Key, window: key.Window}
+
+	if txCtx.DryRun {
+		s.mu.RLock()
+		_, ok := s.m[htKey]
+		s.mu.RUnlock()
+
+		return ok
+	}
 
 	s.mu.Lock()
 	_, ok := s.m[htKey]
@@ -58,9 +170,63 @@ func (s *HashTable) Del(key database.BatchKey) bool {
 	}
 	s.mu.Unlock()
 
+	if ok {
+		s.notify(htKey)
+	}
+
 	return ok
 }
 
+// Watch registers a watcher of the key. The returned channel is closed when the key value is changed.
+// cancel must be called when the watcher is not needed anymore.
+func (s *HashTable) Watch(key database.BatchKey) (ch <-chan struct{}, cancel func()) {
+	htKey := hashTableKey{key: key.Key, window: key.Window}
+
+	s.watchMu.Lock()
+	group, ok := s.watchers[htKey]
+	if !ok {
+		group = &watchGroup{ch: make(chan struct{})}
+		s.watchers[htKey] = group
+	}
+	group.n++
+	s.watching.Add(1)
+	s.watchMu.Unlock()
+
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			s.watchMu.Lock()
+			defer s.watchMu.Unlock()
+
+			s.watching.Add(-1)
+
+			// the group is already removed if watchers were notified
+			if current, ok := s.watchers[htKey]; ok && current == group {
+				group.n--
+				if group.n == 0 {
+					delete(s.watchers, htKey)
+				}
+			}
+		})
+	}
+
+	return group.ch, cancel
+}
+
+// notify wakes watchers of the key.
+func (s *HashTable) notify(key hashTableKey) {
+	if s.watching.Load() == 0 {
+		return
+	}
+
+	s.watchMu.Lock()
+	if group, ok := s.watchers[key]; ok {
+		close(group.ch)
+		delete(s.watchers, key)
+	}
+	s.watchMu.Unlock()
+}
+
 func (s *HashTable) Clean(ctx context.Context) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -72,7 +238,7 @@ func (s *HashTable) Clean(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		default:
-			if isExpiredWithDelta(v.lastTxAt, v.batchSize) {
+			if isExpiredWithDelta(v.lastTxAt, v.window) {
 				keysToDelete = append(keysToDelete, k)
 			}
 		}
@@ -81,6 +247,34 @@ func (s *HashTable) Clean(ctx context.Context) {
 	for _, k := range keysToDelete {
 		delete(s.m, k)
 	}
+
+	// a throttle whose theoretical arrival time has passed is equal to a new one
+	now := time.Now().UnixNano()
+	for k, v := range s.throttles {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			if v.TAT() < now {
+				delete(s.throttles, k)
+			}
+		}
+	}
+}
+
+// Reset removes all keys and throttles, watchers of the keys are woken.
+func (s *HashTable) Reset() {
+	s.mu.Lock()
+	s.m = make(map[hashTableKey]*FqElem)
+	s.throttles = make(map[string]*ThrottleElem)
+	s.mu.Unlock()
+
+	s.watchMu.Lock()
+	for key, group := range s.watchers {
+		close(group.ch)
+		delete(s.watchers, key)
+	}
+	s.watchMu.Unlock()
 }
 
 func (s *HashTable) Dump(ctx context.Context, dumpTx database.Tx, ch chan<- database.DumpElem) {
@@ -96,6 +290,10 @@ func (s *HashTable) Dump(ctx context.Context, dumpTx database.Tx, ch chan<- data
 			elem *FqElem
 		}{k, v})
 	}
+	throttles := make(map[string]*ThrottleElem, len(s.throttles))
+	for k, v := range s.throttles {
+		throttles[k] = v
+	}
 	s.mu.RUnlock()
 
 	for _, item := range items {
@@ -103,34 +301,69 @@ func (s *HashTable) Dump(ctx context.Context, dumpTx database.Tx, ch chan<- data
 		case <-ctx.Done():
 			return
 		default:
-			if isExpired(item.elem.lastTxAt, item.elem.batchSize) {
+			if isExpired(item.elem.lastTxAt, item.elem.window) {
 				continue
 			}
 
-			value, txAt, tx := item.elem.DumpValue(dumpTx)
+			elem, ok := item.elem.DumpValue(dumpTx)
+			if !ok {
+				continue
+			}
 
-			ch <- database.DumpElem{
-				Key:       item.key.key,
-				BatchSize: item.key.batchSize,
-				Value:     value,
-				TxAt:      txAt,
-				Tx:        tx,
+			elem.Key = item.key.key
+			ch <- elem
+		}
+	}
+
+	now := time.Now().UnixNano()
+	for key, throttle := range throttles {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			elem, ok := throttle.DumpValue(dumpTx)
+			if !ok || elem.TAT < now {
+				continue
 			}
+
+			elem.Key = key
+			ch <- elem
 		}
 	}
 }
 
-func (s *HashTable) RestoreDumpElem(elem database.DumpElem) {
-	fqElem := NewFqElem(elem.BatchSize)
+func (s *HashTable) RestoreDumpElem(elem database.DumpElem, window database.Window) {
+	if elem.Kind == database.DumpElemThrottle {
+		throttle := NewThrottleElem()
+		throttle.ver = elem.Tx
+		throttle.tat = elem.TAT
+
+		s.mu.Lock()
+		s.throttles[elem.Key] = throttle
+		s.mu.Unlock()
+
+		return
+	}
+
+	fqElem := s.newElem(window)
 	fqElem.ver = elem.Tx
 	fqElem.lastTxAt = elem.TxAt
 	fqElem.value = elem.Value
+	fqElem.prevValue = elem.PrevValue
+	fqElem.mode = elem.Mode
+	if fqElem.history != nil {
+		for _, entry := range elem.History {
+			fqElem.history.push(entry)
+		}
+	}
 
-	key := hashTableKey{key: elem.Key, batchSize: elem.BatchSize}
+	key := hashTableKey{key: elem.Key, window: window}
 
 	s.mu.Lock()
 	s.m[key] = fqElem
 	s.mu.Unlock()
+
+	s.notify(key)
 }
 
 func (s *HashTable) getOrInitElem(key hashTableKey) *FqElem {
@@ -148,10 +381,57 @@ func (s *HashTable) getOrInitElem(key hashTableKey) *FqElem {
 	// Double-check after acquiring write lock
 	v, ok = s.m[key]
 	if !ok {
-		v = NewFqElem(key.batchSize)
+		v = s.newElem(key.window)
 		s.m[key] = v
 	}
 	s.mu.Unlock()
 
 	return v
 }
+
+// writeElem returns the element changed by a write of the key,
+// a dry run gets a copy of the element, so the table is not changed.
+func (s *HashTable) writeElem(txCtx database.TxContext, key hashTableKey) *FqElem {
+	if !txCtx.DryRun {
+		return s.getOrInitElem(key)
+	}
+
+	s.mu.RLock()
+	v, ok := s.m[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return NewFqElem(key.window)
+	}
+
+	return v.copyState()
+}
+
+func (s *HashTable) newElem(window database.Window) *FqElem {
+	elem := NewFqElem(window)
+	if s.historySize > 0 {
+		elem.history = newWindowHistory(s.historySize)
+	}
+
+	return elem
+}
+
+func (s *HashTable) getOrInitThrottle(key string) *ThrottleElem {
+	s.mu.RLock()
+	v, ok := s.throttles[key]
+	s.mu.RUnlock()
+
+	if ok {
+		return v
+	}
+
+	s.mu.Lock()
+	v, ok = s.throttles[key]
+	if !ok {
+		v = NewThrottleElem()
+		s.throttles[key] = v
+	}
+	s.mu.Unlock()
+
+	return v
+}
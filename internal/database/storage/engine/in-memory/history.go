@@ -0,0 +1,65 @@
+package inmemory
+
+import (
+	"fq/internal/database"
+)
+
+// windowHistory is a ring buffer of values of closed windows ordered by window start.
+// Windows without events are not stored.
+type windowHistory struct {
+	entries []database.HistoryEntry
+	head    int // index of the oldest entry
+	size    int
+}
+
+func newWindowHistory(capacity int) *windowHistory {
+	return &windowHistory{
+		entries: make([]database.HistoryEntry, capacity),
+	}
+}
+
+// push adds the entry of a window newer than all stored ones, evicting the oldest entry if the buffer is full.
+func (h *windowHistory) push(entry database.HistoryEntry) {
+	if entry.Value == 0 || len(h.entries) == 0 {
+		return
+	}
+
+	if h.size < len(h.entries) {
+		h.entries[(h.head+h.size)%len(h.entries)] = entry
+		h.size++
+
+		return
+	}
+
+	h.entries[h.head] = entry
+	h.head = (h.head + 1) % len(h.entries)
+}
+
+// list returns stored entries from the oldest to the newest.
+func (h *windowHistory) list() []database.HistoryEntry {
+	if h == nil {
+		return nil
+	}
+
+	res := make([]database.HistoryEntry, 0, h.size)
+	for i := 0; i < h.size; i++ {
+		res = append(res, h.entries[(h.head+i)%len(h.entries)])
+	}
+
+	return res
+}
+
+func (h *windowHistory) clone() *windowHistory {
+	if h == nil {
+		return nil
+	}
+
+	entries := make([]database.HistoryEntry, len(h.entries))
+	copy(entries, h.entries)
+
+	return &windowHistory{
+		entries: entries,
+		head:    h.head,
+		size:    h.size,
+	}
+}
@@ -0,0 +1,35 @@
+package inmemory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+)
+
+func TestWindowHistory(t *testing.T) {
+	h := newWindowHistory(3)
+	require.Empty(t, h.list())
+
+	h.push(database.HistoryEntry{Start: 100, Value: 1})
+	h.push(database.HistoryEntry{Start: 200, Value: 0})
+	h.push(database.HistoryEntry{Start: 300, Value: 3})
+	require.Equal(t, []database.HistoryEntry{{Start: 100, Value: 1}, {Start: 300, Value: 3}}, h.list())
+
+	c := h.clone()
+
+	h.push(database.HistoryEntry{Start: 400, Value: 4})
+	h.push(database.HistoryEntry{Start: 500, Value: 5})
+	require.Equal(t, []database.HistoryEntry{
+		{Start: 300, Value: 3},
+		{Start: 400, Value: 4},
+		{Start: 500, Value: 5},
+	}, h.list())
+
+	require.Equal(t, []database.HistoryEntry{{Start: 100, Value: 1}, {Start: 300, Value: 3}}, c.list())
+
+	var disabled *windowHistory
+	require.Nil(t, disabled.list())
+	require.Nil(t, disabled.clone())
+}
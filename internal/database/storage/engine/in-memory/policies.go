@@ -0,0 +1,103 @@
+package inmemory
+
+import (
+	"sync"
+
+	"fq/internal/database"
+)
+
+// policyRegistry keeps named policies: static ones defined in the config
+// and dynamic ones created at runtime. Dynamic policies are written to the WAL and dumps.
+type policyRegistry struct {
+	mu       sync.RWMutex
+	policies map[string]database.Policy
+}
+
+func newPolicyRegistry() *policyRegistry {
+	return &policyRegistry{
+		policies: make(map[string]database.Policy),
+	}
+}
+
+func (r *policyRegistry) get(name string) (database.Policy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policy, ok := r.policies[name]
+
+	return policy, ok
+}
+
+func (r *policyRegistry) set(policy database.Policy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.policies[policy.Name]; ok && current.Static {
+		return database.ErrPolicyReadOnly
+	}
+
+	r.policies[policy.Name] = policy
+
+	return nil
+}
+
+func (r *policyRegistry) del(name string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.policies[name]
+	if !ok {
+		return false, nil
+	}
+
+	if current.Static {
+		return false, database.ErrPolicyReadOnly
+	}
+
+	delete(r.policies, name)
+
+	return true, nil
+}
+
+// setStatic replaces static policies, dynamic policies with the same names are overridden.
+func (r *policyRegistry) setStatic(policies []database.Policy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, policy := range r.policies {
+		if policy.Static {
+			delete(r.policies, name)
+		}
+	}
+
+	for _, policy := range policies {
+		policy.Static = true
+		r.policies[policy.Name] = policy
+	}
+}
+
+// resetDynamic removes dynamic policies, static policies are kept.
+func (r *policyRegistry) resetDynamic() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, policy := range r.policies {
+		if !policy.Static {
+			delete(r.policies, name)
+		}
+	}
+}
+
+func (r *policyRegistry) dynamic() []database.Policy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]database.Policy, 0, len(r.policies))
+	for _, policy := range r.policies {
+		if !policy.Static {
+			res = append(res, policy)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,101 @@
+package inmemory
+
+import (
+	"path"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+
+	"fq/internal/database"
+)
+
+// subscriptionBufferSize is the number of events buffered for a subscriber,
+// events are dropped when a subscriber doesn't keep up.
+const subscriptionBufferSize = 1024
+
+type thresholdSubscription struct {
+	pattern string
+	window  database.Window
+	limit   database.ValueType
+	ch      chan database.ThresholdEvent
+}
+
+// subscriptionRegistry keeps threshold subscriptions and notifies them about written values.
+type subscriptionRegistry struct {
+	mu     sync.RWMutex
+	subs   map[*thresholdSubscription]struct{}
+	n      atomic.Int64 // number of subscriptions, to skip notifications without locking
+	logger *zerolog.Logger
+}
+
+func newSubscriptionRegistry(logger *zerolog.Logger) *subscriptionRegistry {
+	return &subscriptionRegistry{
+		subs:   make(map[*thresholdSubscription]struct{}),
+		logger: logger,
+	}
+}
+
+func (r *subscriptionRegistry) subscribe(
+	pattern string,
+	window database.Window,
+	limit database.ValueType,
+) (<-chan database.ThresholdEvent, func(), error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, nil, database.ErrInvalidKeyPattern
+	}
+
+	sub := &thresholdSubscription{
+		pattern: pattern,
+		window:  window,
+		limit:   limit,
+		ch:      make(chan database.ThresholdEvent, subscriptionBufferSize),
+	}
+
+	r.mu.Lock()
+	r.subs[sub] = struct{}{}
+	r.n.Add(1)
+	r.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.subs, sub)
+			r.n.Add(-1)
+			r.mu.Unlock()
+		})
+	}
+
+	return sub.ch, cancel, nil
+}
+
+// notify sends events to subscribers whose limit is between the value before the write (exclusive)
+// and the value after it (inclusive).
+func (r *subscriptionRegistry) notify(key database.BatchKey, before, after database.ValueType) {
+	if r.n.Load() == 0 || before >= after {
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for sub := range r.subs {
+		if sub.window != key.Window || before >= sub.limit || after < sub.limit {
+			continue
+		}
+
+		if matched, _ := path.Match(sub.pattern, key.Key); !matched {
+			continue
+		}
+
+		select {
+		case sub.ch <- database.ThresholdEvent{Key: key.Key, Window: key.Window, Limit: sub.limit, Value: after}:
+		default:
+			r.logger.Warn().
+				Str("pattern", sub.pattern).
+				Str("key", key.Key).
+				Msg("threshold subscriber is too slow, event dropped")
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package inmemory
+
+import (
+	"sync"
+	"time"
+
+	"fq/internal/database"
+)
+
+// ThrottleElem keeps the GCRA state of a throttled key.
+type ThrottleElem struct {
+	ver database.Tx
+	tat int64 // theoretical arrival time in unix nanoseconds
+
+	dumpVer database.Tx
+	dumpTat int64
+
+	mu sync.RWMutex
+}
+
+func NewThrottleElem() *ThrottleElem {
+	return &ThrottleElem{
+		ver:     database.NoTx,
+		dumpVer: database.NoTx,
+	}
+}
+
+// Throttle admits key.Quantity requests at the moment now (unix nanoseconds)
+// if it doesn't exceed the rate limit described by the key.
+func (e *ThrottleElem) Throttle(
+	txCtx database.TxContext,
+	key database.ThrottleKey,
+	now int64,
+) database.ThrottleResult {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	interval := int64(key.EmissionInterval())
+	tolerance := int64(key.DelayVariationTolerance())
+
+	tat := e.tat
+	if tat < now {
+		tat = now
+	}
+
+	newTat := tat + interval*key.Quantity
+	allowAt := newTat - tolerance
+
+	result := database.ThrottleResult{
+		Limit: key.Burst + 1,
+	}
+
+	if now < allowAt {
+		result.Remaining = max(0, (now-(tat-tolerance))/interval)
+		result.RetryAfter = time.Duration(allowAt - now)
+		result.ResetAfter = time.Duration(tat - now)
+
+		return result
+	}
+
+	if !txCtx.DryRun {
+		e.setLocked(txCtx, newTat)
+	}
+
+	result.Allowed = true
+	result.Remaining = (now - (newTat - tolerance)) / interval
+	result.ResetAfter = time.Duration(newTat - now)
+
+	return result
+}
+
+// SetTAT sets the theoretical arrival time left by an admitted request, e.g. logged to the WAL.
+func (e *ThrottleElem) SetTAT(txCtx database.TxContext, tat int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.setLocked(txCtx, tat)
+}
+
+func (e *ThrottleElem) setLocked(txCtx database.TxContext, tat int64) {
+	if e.dumpVer != txCtx.DumpTx {
+		if txCtx.Tx == txCtx.DumpTx {
+			e.dumpTat = tat
+			e.dumpVer = txCtx.Tx
+		} else {
+			e.dumpTat = e.tat
+			e.dumpVer = e.ver
+		}
+	}
+
+	e.tat = tat
+	e.ver = txCtx.Tx
+}
+
+// TAT returns the theoretical arrival time in unix nanoseconds.
+func (e *ThrottleElem) TAT() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.tat
+}
+
+// DumpValue returns the state of the element as of dumpTx.
+// ok is false if the element didn't exist at that moment.
+func (e *ThrottleElem) DumpValue(dumpTx database.Tx) (elem database.DumpElem, ok bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	elem.Kind = database.DumpElemThrottle
+
+	if e.ver <= dumpTx {
+		elem.TAT = e.tat
+		elem.Tx = e.ver
+
+		return elem, true
+	}
+
+	if e.dumpVer <= dumpTx {
+		elem.TAT = e.dumpTat
+		elem.Tx = e.dumpVer
+
+		return elem, true
+	}
+
+	return database.DumpElem{}, false
+}
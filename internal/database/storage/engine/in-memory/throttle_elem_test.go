@@ -0,0 +1,64 @@
+package inmemory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+)
+
+func TestThrottleElem_Throttle(t *testing.T) {
+	e := NewThrottleElem()
+	key := database.ThrottleKey{Key: "key", Rate: 10, Period: 1, Burst: 2, Quantity: 1}
+	now := time.Now().UnixNano()
+
+	for i, remaining := range []int64{2, 1, 0} {
+		res := e.Throttle(database.TxContext{Tx: database.Tx(i + 1)}, key, now)
+		require.True(t, res.Allowed)
+		require.Equal(t, int64(3), res.Limit)
+		require.Equal(t, remaining, res.Remaining)
+		require.Equal(t, time.Duration(0), res.RetryAfter)
+		require.Equal(t, time.Duration(i+1)*100*time.Millisecond, res.ResetAfter)
+	}
+
+	res := e.Throttle(database.TxContext{Tx: 4}, key, now)
+	require.False(t, res.Allowed)
+	require.Equal(t, int64(0), res.Remaining)
+	require.Equal(t, 100*time.Millisecond, res.RetryAfter)
+	require.Equal(t, 300*time.Millisecond, res.ResetAfter)
+	require.Equal(t, database.Tx(3), e.ver)
+
+	peek := key
+	peek.Quantity = 0
+	res = e.Throttle(database.TxContext{Tx: 5}, peek, now+int64(150*time.Millisecond))
+	require.True(t, res.Allowed)
+	require.Equal(t, int64(1), res.Remaining)
+
+	res = e.Throttle(database.TxContext{Tx: 6}, key, now+int64(100*time.Millisecond))
+	require.True(t, res.Allowed)
+	require.Equal(t, int64(0), res.Remaining)
+}
+
+func TestThrottleElem_DumpValue(t *testing.T) {
+	e := NewThrottleElem()
+	key := database.ThrottleKey{Key: "key", Rate: 1, Period: 1, Burst: 5, Quantity: 1}
+	now := time.Now().UnixNano()
+
+	e.Throttle(database.TxContext{Tx: 1, DumpTx: database.NoTx}, key, now)
+	e.Throttle(database.TxContext{Tx: 3, DumpTx: 2}, key, now)
+
+	elem, ok := e.DumpValue(2)
+	require.True(t, ok)
+	require.Equal(t, database.DumpElemThrottle, elem.Kind)
+	require.Equal(t, now+int64(time.Second), elem.TAT)
+	require.Equal(t, database.Tx(1), elem.Tx)
+
+	elem, ok = e.DumpValue(3)
+	require.True(t, ok)
+	require.Equal(t, now+int64(2*time.Second), elem.TAT)
+
+	_, ok = e.DumpValue(0)
+	require.False(t, ok)
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"slices"
+	"sync"
+
+	"fq/internal/database"
+)
+
+const keyLockStripes = 256
+
+// keyLocks serializes logged writes of the same keys, so the result of a write
+// computed before it is logged doesn't change until the write is applied.
+type keyLocks struct {
+	stripes [keyLockStripes]sync.Mutex
+}
+
+// lock locks stripes of the keys in ascending order, so writes of overlapping keys don't deadlock.
+func (l *keyLocks) lock(keys ...database.BatchKey) (unlock func()) {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, key.Key)
+	}
+
+	return l.lockNames(names...)
+}
+
+// lockNames locks stripes of keys by their names, e.g. of throttled keys.
+func (l *keyLocks) lockNames(names ...string) (unlock func()) {
+	idxs := make([]int, 0, len(names))
+	for _, name := range names {
+		idxs = append(idxs, stripeIdx(name))
+	}
+
+	slices.Sort(idxs)
+	idxs = slices.Compact(idxs)
+
+	for _, idx := range idxs {
+		l.stripes[idx].Lock()
+	}
+
+	return func() {
+		for i := len(idxs) - 1; i >= 0; i-- {
+			l.stripes[idxs[i]].Unlock()
+		}
+	}
+}
+
+func stripeIdx(key string) int {
+	hash := uint32(0)
+	for i := 0; i < len(key); i++ {
+		hash = hash*31 + uint32(key[i])
+	}
+
+	return int(hash % keyLockStripes)
+}
@@ -0,0 +1,71 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fq/internal/database/storage/wal"
+)
+
+// CDCConsumer reads counter changes decoded from the WAL of a master through its replication port.
+type CDCConsumer struct {
+	client  TCPClient
+	nextLSN uint64
+	limit   int
+}
+
+// NewCDCConsumer makes a consumer reading changes of WAL records starting from fromLSN (LSNs start from 1).
+// limit is the max number of WAL records read at once, 0 means the master default.
+func NewCDCConsumer(client TCPClient, fromLSN uint64, limit int) (*CDCConsumer, error) {
+	if client == nil {
+		return nil, errors.New("client is invalid")
+	}
+
+	if fromLSN == 0 {
+		return nil, errors.New("LSN must be positive")
+	}
+
+	return &CDCConsumer{
+		client:  client,
+		nextLSN: fromLSN,
+		limit:   limit,
+	}, nil
+}
+
+// Read returns the next changes, there are none if the master has no new WAL records.
+// It returns wal.ErrLSNTooOld if the WAL records were removed from the master.
+func (c *CDCConsumer) Read(ctx context.Context) ([]wal.Change, error) {
+	requestData, err := Encode(NewCDCRequest(c.nextLSN, c.limit))
+	if err != nil {
+		return nil, fmt.Errorf("encode CDC request: %w", err)
+	}
+
+	responseData, err := c.client.Send(ctx, requestData)
+	if err != nil {
+		return nil, fmt.Errorf("send CDC request: %w", err)
+	}
+
+	reply, err := decodeResponse(responseData, MessageType_MESSAGE_TYPE_CDC)
+	if err != nil {
+		return nil, fmt.Errorf("CDC response: %w", err)
+	}
+
+	response := reply.GetCdc()
+	if response.GetLsnTooOld() {
+		return nil, fmt.Errorf("%w: %d (oldest retained LSN is %d)", wal.ErrLSNTooOld, c.nextLSN, response.GetOldestLsn())
+	}
+
+	if !response.GetSucceed() {
+		return nil, errors.New("failed to read changes: master error")
+	}
+
+	c.nextLSN = response.GetNextLsn()
+
+	return changesFromProto(response.GetChanges()), nil
+}
+
+// NextLSN returns the LSN the consumer continues from, it can be stored to resume reading later.
+func (c *CDCConsumer) NextLSN() uint64 {
+	return c.nextLSN
+}
@@ -0,0 +1,73 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var errForwardNotFramed = errors.New("master doesn't support the framed protocol")
+
+// Forwarder sends writes of a slave to the master over a client connection.
+// Writes are sent one by one over a single connection, which is opened on the first write
+// and reopened after an error. A failed write is not retried, the master may have applied it.
+// The connection must be framed, so the typed reply of the master is read as a whole.
+type Forwarder struct {
+	clientFactory TCPClientFactory
+	client        TCPClient
+	mutex         sync.Mutex
+}
+
+func NewForwarder(clientFactory TCPClientFactory) (*Forwarder, error) {
+	if clientFactory == nil {
+		return nil, errors.New("clientFactory is invalid")
+	}
+
+	return &Forwarder{
+		clientFactory: clientFactory,
+	}, nil
+}
+
+func (f *Forwarder) Forward(ctx context.Context, query string) (string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.client == nil {
+		client, err := f.clientFactory.Create()
+		if err != nil {
+			return "", err
+		}
+
+		if framed, ok := client.(interface{ Framed() bool }); ok && !framed.Framed() {
+			_ = client.Close()
+
+			return "", errForwardNotFramed
+		}
+
+		f.client = client
+	}
+
+	response, err := f.client.Send(ctx, []byte(query))
+	if err != nil {
+		_ = f.client.Close()
+		f.client = nil
+
+		return "", err
+	}
+
+	return string(response), nil
+}
+
+func (f *Forwarder) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.client == nil {
+		return nil
+	}
+
+	err := f.client.Close()
+	f.client = nil
+
+	return err
+}
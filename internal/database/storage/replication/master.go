@@ -6,6 +6,8 @@ import (
 	"fmt"
 
 	"github.com/rs/zerolog"
+
+	"fq/internal/database/storage/wal"
 )
 
 type TCPServer interface {
@@ -15,7 +17,9 @@ type TCPServer interface {
 type Master struct {
 	server       TCPServer
 	walDirectory string
+	walReader    *wal.FSReader
 	dumpProvider DumpProvider
+	notifier     *wal.FlushNotifier
 	logger       *zerolog.Logger
 }
 
@@ -36,11 +40,18 @@ func NewMaster(
 	return &Master{
 		server:       server,
 		walDirectory: walDirectory,
+		walReader:    wal.NewFSReader(walDirectory, logger),
 		dumpProvider: dumpProvider,
 		logger:       logger,
 	}, nil
 }
 
+// SetFlushNotifier sets the notifier of the WAL writer, requests of slaves which are up to date
+// are held until new batches are written. Without it slaves poll the master.
+func (m *Master) SetFlushNotifier(notifier *wal.FlushNotifier) {
+	m.notifier = notifier
+}
+
 func (m *Master) IsMaster() bool {
 	return true
 }
@@ -56,8 +67,8 @@ func (m *Master) Start(ctx context.Context) error {
 			return nil, fmt.Errorf("empty replication request")
 		}
 
-		var request Request
-		if err := Decode(&request, requestData); err != nil {
+		request := &Request{}
+		if err := Decode(request, requestData); err != nil {
 			m.logger.Warn().
 				Err(err).
 				Int("request_size", len(requestData)).
@@ -65,10 +76,51 @@ func (m *Master) Start(ctx context.Context) error {
 			return nil, fmt.Errorf("failed to decode replication request: %w", err)
 		}
 
-		if request.DumpRequest.SessionUUID != "" {
-			return m.processDump(request.DumpRequest), nil
+		responseData, err := Encode(m.handleRequest(ctx, request))
+		if err != nil {
+			m.logger.Error().Err(err).Stringer("message_type", request.GetType()).Msg("failed to encode replication response")
+
+			return nil, err
 		}
 
-		return m.processWAL(request.WALRequest), nil
+		return responseData, nil
 	})
 }
+
+// handleRequest dispatches a request by its message type, a request of an unknown type
+// or of an unsupported protocol version is answered with an error.
+func (m *Master) handleRequest(ctx context.Context, request *Request) *Response {
+	response := &Response{Version: ProtocolVersion, Type: request.GetType()}
+
+	if version := request.GetVersion(); version < MinProtocolVersion || version > ProtocolVersion {
+		response.Error = fmt.Sprintf("%v: %d (supported %d-%d)", ErrUnsupportedVersion, version, MinProtocolVersion, ProtocolVersion)
+
+		return response
+	}
+
+	switch request.GetType() {
+	case MessageType_MESSAGE_TYPE_HANDSHAKE:
+		response.Handshake = &HandshakeResponse{Capabilities: uint64(m.capabilities())}
+	case MessageType_MESSAGE_TYPE_DUMP:
+		response.Dump = m.synchronizeDump(request.GetDump())
+	case MessageType_MESSAGE_TYPE_WAL:
+		response.Wal = m.waitWAL(ctx, request.GetWal())
+	case MessageType_MESSAGE_TYPE_CDC:
+		response.Cdc = m.readChanges(ctx, request.GetCdc())
+	default:
+		m.logger.Warn().Int32("message_type", int32(request.GetType())).Msg("unknown replication message type")
+		response.Error = fmt.Sprintf("%v: %d", ErrUnknownMessageType, request.GetType())
+	}
+
+	return response
+}
+
+func (m *Master) capabilities() Capabilities {
+	capabilities := supportedCapabilities
+	if m.notifier == nil {
+		// WAL requests can't be held without the notifier of the WAL writer
+		capabilities &^= NewCapabilities(Capability_CAPABILITY_WAL_WAIT)
+	}
+
+	return capabilities
+}
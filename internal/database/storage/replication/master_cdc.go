@@ -0,0 +1,55 @@
+package replication
+
+import (
+	"context"
+	"errors"
+
+	"fq/internal/database/storage/wal"
+)
+
+const (
+	defaultCDCLimit = 1000
+	maxCDCLimit     = 10000
+)
+
+func (m *Master) readChanges(ctx context.Context, request *CDCRequest) *CDCResponse {
+	limit := int(request.GetLimit())
+	if limit <= 0 {
+		limit = defaultCDCLimit
+	}
+
+	if limit > maxCDCLimit {
+		limit = maxCDCLimit
+	}
+
+	logs, oldestLSN, err := m.walReader.ReadLogsFrom(ctx, request.GetFromLsn(), limit)
+	if err != nil {
+		if errors.Is(err, wal.ErrLSNTooOld) {
+			return &CDCResponse{LsnTooOld: true, OldestLsn: oldestLSN}
+		}
+
+		m.logger.Error().Err(err).Uint64("from_lsn", request.GetFromLsn()).Msg("failed to read WAL logs for CDC")
+
+		return &CDCResponse{}
+	}
+
+	response := &CDCResponse{
+		Succeed:   true,
+		NextLsn:   request.GetFromLsn(),
+		OldestLsn: oldestLSN,
+	}
+
+	for _, log := range logs {
+		changes, err := wal.DecodeChanges(log)
+		if err != nil {
+			m.logger.Error().Err(err).Uint64("lsn", log.LSN).Msg("failed to decode WAL log for CDC")
+
+			return &CDCResponse{}
+		}
+
+		response.Changes = append(response.Changes, changesToProto(changes)...)
+		response.NextLsn = log.LSN + 1
+	}
+
+	return response
+}
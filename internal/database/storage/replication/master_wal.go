@@ -1,81 +1,152 @@
 package replication
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"fq/internal/database/storage/wal"
 )
 
-func (m *Master) processWAL(request WALRequest) []byte {
-	response := m.synchronizeWAL(request)
-	responseData, err := Encode(&response)
-	if err != nil {
-		m.logger.Error().Err(err).Msg("failed to encode WAL replication response")
+var errSegmentOffsetOutOfRange = errors.New("offset is beyond the end of WAL segment")
+
+// maxWALWaitTimeout limits the time a WAL request of a slave is held.
+const maxWALWaitTimeout = 30 * time.Second
+
+// waitWAL holds a request of a slave which has received all WAL data until
+// new batches are written to the WAL or the wait timeout of the request expires.
+func (m *Master) waitWAL(ctx context.Context, request *WALRequest) *WALResponse {
+	waitTimeout := time.Duration(request.GetWaitTimeoutMs()) * time.Millisecond
+	if m.notifier == nil || waitTimeout <= 0 {
+		return m.synchronizeWAL(request)
 	}
 
-	return responseData
+	timer := time.NewTimer(min(waitTimeout, maxWALWaitTimeout))
+	defer timer.Stop()
+
+	for {
+		// the channel is taken before segments are read, so a flush in between is not missed
+		flushed := m.notifier.Flushed()
+
+		response := m.synchronizeWAL(request)
+		if !response.Succeed || response.SegmentName != "" {
+			return response
+		}
+
+		select {
+		case <-flushed:
+		case <-timer.C:
+			return response
+		case <-ctx.Done():
+			return response
+		}
+	}
 }
 
-func (m *Master) synchronizeWAL(request WALRequest) WALResponse {
-	// First, try to find a new segment with name greater than lastSegmentName
-	segmentName, err := wal.SegmentUpperBound(m.walDirectory, request.LastSegmentName)
+func (m *Master) synchronizeWAL(request *WALRequest) *WALResponse {
+	// The rest of the segment of the slave is sent before the next segment
+	if request.GetLastSegmentName() != "" {
+		lastSegmentPath := filepath.Join(m.walDirectory, request.GetLastSegmentName())
+		offset := request.GetOffset()
+
+		data, err := readSegmentFrom(lastSegmentPath, offset)
+		if errors.Is(err, errSegmentOffsetOutOfRange) {
+			m.logger.Warn().
+				Str("last_segment_name", request.GetLastSegmentName()).
+				Int64("offset", offset).
+				Msg("slave is ahead of WAL segment, sending the whole segment")
+
+			offset = 0
+			data, err = readSegmentFrom(lastSegmentPath, offset)
+		}
+
+		switch {
+		case err == nil && len(data) > 0:
+			m.logger.Debug().
+				Str("last_segment_name", request.GetLastSegmentName()).
+				Int64("offset", offset).
+				Int("data_size", len(data)).
+				Msg("sending appended WAL data to slave")
+
+			return &WALResponse{
+				Succeed:     true,
+				SegmentName: request.GetLastSegmentName(),
+				SegmentData: data,
+				Offset:      offset,
+			}
+		case err != nil && !errors.Is(err, os.ErrNotExist):
+			m.logger.Error().Err(err).Str("segment_name", request.GetLastSegmentName()).Msg("failed to read WAL segment")
+
+			return &WALResponse{}
+		}
+	}
+
+	// Then, try to find a new segment with name greater than lastSegmentName
+	segmentName, err := wal.SegmentUpperBound(m.walDirectory, request.GetLastSegmentName())
 	if err != nil {
 		m.logger.Error().Err(err).Msg("failed to find WAL segment")
 
-		return WALResponse{}
+		return &WALResponse{}
 	}
 
-	// If no new segment found, check if the last segment has been updated
 	if segmentName == "" {
-		// If we have a last segment name, check if it still exists and has grown
-		if request.LastSegmentName != "" {
-			lastSegmentPath := filepath.Join(m.walDirectory, request.LastSegmentName)
-			_, err := os.Stat(lastSegmentPath)
-			if err == nil {
-				// File exists, read it to check if it has new data
-				data, readErr := os.ReadFile(lastSegmentPath)
-				if readErr == nil {
-					// Check if file has grown (has more data than before)
-					// We always send the full segment, slave will filter by LSN
-					m.logger.Debug().
-						Str("last_segment_name", request.LastSegmentName).
-						Int("segment_size", len(data)).
-						Msg("sending updated segment to slave")
-					return WALResponse{
-						Succeed:     true,
-						SegmentData: data,
-						SegmentName: request.LastSegmentName,
-					}
-				}
-			}
-		}
-
-		// No updates found
 		m.logger.Debug().
-			Str("last_segment_name", request.LastSegmentName).
+			Str("last_segment_name", request.GetLastSegmentName()).
 			Msg("no new WAL segments to replicate")
-		return WALResponse{Succeed: true}
+
+		return &WALResponse{Succeed: true}
 	}
 
-	// New segment found
-	filename := filepath.Join(m.walDirectory, segmentName)
-	data, err := os.ReadFile(filename)
+	data, err := readSegmentFrom(filepath.Join(m.walDirectory, segmentName), 0)
 	if err != nil {
 		m.logger.Error().Err(err).Str("segment_name", segmentName).Msg("failed to read WAL segment")
 
-		return WALResponse{}
+		return &WALResponse{}
+	}
+
+	// A segment without complete batches is sent when the first batch is written
+	if len(data) == 0 {
+		return &WALResponse{Succeed: true}
 	}
 
 	m.logger.Info().
 		Str("segment_name", segmentName).
-		Str("last_segment_name", request.LastSegmentName).
+		Str("last_segment_name", request.GetLastSegmentName()).
 		Int("segment_size", len(data)).
 		Msg("sending WAL segment to slave")
 
-	return WALResponse{
+	return &WALResponse{
 		Succeed:     true,
 		SegmentData: data,
 		SegmentName: segmentName,
 	}
 }
+
+// readSegmentFrom reads complete batches of the segment following offset bytes.
+func readSegmentFrom(filename string, offset int64) ([]byte, error) {
+	segment, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer segment.Close()
+
+	info, err := segment.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() < offset {
+		return nil, fmt.Errorf("%w: %d (size %d)", errSegmentOffsetOutOfRange, offset, info.Size())
+	}
+
+	data := make([]byte, info.Size()-offset)
+	if _, err := segment.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return data[:wal.CompleteBatchesSize(data)], nil
+}
@@ -0,0 +1,192 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+
+	"fq/internal/database"
+	"fq/internal/database/storage/wal"
+)
+
+// NodeFactory makes the master and slaves of a node when its role changes.
+type NodeFactory interface {
+	NewMaster() (*Master, error)
+	NewSlave(masterAddress string) (*Slave, error)
+}
+
+// Node runs the replication role of the node: the master listener or the slave loop.
+// The role is changed at runtime by Promote and ReplicaOf, the storage stops writes meanwhile.
+type Node struct {
+	factory      NodeFactory
+	walDirectory string
+
+	mutex      sync.Mutex
+	ctx        context.Context
+	master     *Master
+	slave      *Slave
+	stopMaster context.CancelFunc
+	masterDone chan struct{}
+	isMaster   atomic.Bool
+
+	logger *zerolog.Logger
+}
+
+// NewNode makes a master node, or a slave node of the master when masterAddress is not empty.
+func NewNode(factory NodeFactory, masterAddress string, walDirectory string, logger *zerolog.Logger) (*Node, error) {
+	if factory == nil {
+		return nil, errors.New("factory is invalid")
+	}
+
+	if logger == nil {
+		return nil, errors.New("logger is invalid")
+	}
+
+	node := &Node{
+		factory:      factory,
+		walDirectory: walDirectory,
+		logger:       logger,
+	}
+
+	if masterAddress == "" {
+		master, err := factory.NewMaster()
+		if err != nil {
+			return nil, err
+		}
+
+		node.master = master
+		node.isMaster.Store(true)
+
+		return node, nil
+	}
+
+	slave, err := factory.NewSlave(masterAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	node.slave = slave
+
+	return node, nil
+}
+
+func (n *Node) IsMaster() bool {
+	return n.isMaster.Load()
+}
+
+func (n *Node) Start(ctx context.Context) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.ctx = ctx
+	if n.master != nil {
+		n.startMaster()
+	} else {
+		n.slave.Start(ctx)
+	}
+}
+
+func (n *Node) Shutdown() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.stop()
+}
+
+// Promote stops the slave and starts the master listener. It returns the LSN
+// of the last change received from the old master, new changes continue from it.
+func (n *Node) Promote() (uint64, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.master != nil {
+		return 0, nil
+	}
+
+	master, err := n.factory.NewMaster()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create master: %w", err)
+	}
+
+	slave := n.slave
+	slave.Shutdown()
+	n.slave = nil
+
+	n.master = master
+	n.isMaster.Store(true)
+	n.startMaster()
+
+	lastLSN := slave.LastLSN()
+	n.logger.Info().Uint64("last_lsn", lastLSN).Msg("promoted to master")
+
+	return lastLSN, nil
+}
+
+// ReplicaOf makes the node a slave of the master, the WAL writer must be stopped by the caller.
+// The WAL segments of the node are archived, since the history of the master replaces them.
+// reset clears the state of the node before the slave synchronizes the dump of the master,
+// as LSNs of the node and of the master are not comparable after they diverged.
+func (n *Node) ReplicaOf(masterAddress string, reset func()) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	// the slave connects to the master, so an unreachable master doesn't change the role
+	slave, err := n.factory.NewSlave(masterAddress)
+	if err != nil {
+		return fmt.Errorf("%w: %w", database.ErrMasterUnavailable, err)
+	}
+
+	n.stop()
+
+	archive, err := wal.ArchiveSegments(n.walDirectory)
+	if err != nil {
+		n.logger.Error().Err(err).Msg("failed to archive WAL segments")
+	} else if archive != "" {
+		n.logger.Info().Str("archive", archive).Msg("WAL segments are archived")
+	}
+
+	reset()
+
+	slave.lastSegmentName = ""
+	slave.lastSegmentSize = 0
+
+	n.master = nil
+	n.slave = slave
+	n.isMaster.Store(false)
+	n.slave.Start(n.ctx)
+
+	n.logger.Info().Str("master_address", masterAddress).Msg("replicating the master")
+
+	return nil
+}
+
+func (n *Node) startMaster() {
+	ctx, cancel := context.WithCancel(n.ctx)
+	done := make(chan struct{})
+	n.stopMaster, n.masterDone = cancel, done
+
+	go func(master *Master) {
+		defer close(done)
+
+		if err := master.Start(ctx); err != nil {
+			n.logger.Error().Err(err).Msg("replication master stopped")
+		}
+	}(n.master)
+}
+
+// stop stops the current role.
+func (n *Node) stop() {
+	if n.stopMaster != nil {
+		n.stopMaster()
+		<-n.masterDone
+		n.stopMaster, n.masterDone = nil, nil
+	}
+
+	if n.slave != nil {
+		n.slave.Shutdown()
+	}
+}
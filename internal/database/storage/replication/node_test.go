@@ -0,0 +1,98 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database/storage/wal"
+)
+
+// unreachableMaster makes clients of a master which doesn't answer.
+type unreachableMaster struct {
+	sends atomic.Int64
+}
+
+func (m *unreachableMaster) Create() (TCPClient, error) {
+	return m, nil
+}
+
+func (m *unreachableMaster) Send(context.Context, []byte) ([]byte, error) {
+	m.sends.Add(1)
+
+	return nil, errors.New("connection refused")
+}
+
+func (m *unreachableMaster) Close() error {
+	return nil
+}
+
+type idleServer struct{}
+
+func (idleServer) Start(ctx context.Context, _ func(context.Context, []byte) ([]byte, error)) error {
+	<-ctx.Done()
+
+	return nil
+}
+
+type testNodeFactory struct {
+	master    *unreachableMaster
+	directory string
+	logger    *zerolog.Logger
+}
+
+func (f *testNodeFactory) NewMaster() (*Master, error) {
+	return NewMaster(idleServer{}, f.directory, nil, f.logger)
+}
+
+func (f *testNodeFactory) NewSlave(string) (*Slave, error) {
+	slave, err := NewSlaveWithFactory(f.master, wal.NewFSReader(f.directory, f.logger), nil, nil, f.directory, time.Millisecond, f.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// the slave waits for maxRetryDelay after the first error
+	slave.maxRetries = 1
+
+	return slave, nil
+}
+
+func TestNode_PromoteUnreachableMaster(t *testing.T) {
+	logger := zerolog.Nop()
+	factory := &testNodeFactory{
+		master:    &unreachableMaster{},
+		directory: t.TempDir(),
+		logger:    &logger,
+	}
+
+	node, err := NewNode(factory, "master:1946", factory.directory, &logger)
+	require.NoError(t, err)
+
+	node.Start(context.Background())
+	defer node.Shutdown()
+
+	// the slave failed to reach the master and entered the wait mode
+	require.Eventually(t, func() bool {
+		return factory.master.sends.Load() > 0
+	}, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	promoted := make(chan error, 1)
+	go func() {
+		_, err := node.Promote()
+		promoted <- err
+	}()
+
+	select {
+	case err := <-promoted:
+		require.NoError(t, err)
+		require.True(t, node.IsMaster())
+	case <-time.After(time.Second):
+		t.Fatal("promote is blocked by the slave waiting for the master")
+	}
+}
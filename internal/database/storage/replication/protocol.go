@@ -1,70 +1,209 @@
 package replication
 
 import (
-	"bytes"
-	"encoding/gob"
+	"errors"
 	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
 
 	"fq/internal/database"
+	"fq/internal/database/storage/wal"
 )
 
-type Request struct {
-	DumpRequest
-	WALRequest
-}
+// The replication protocol is defined in replication.proto, a master handles requests
+// of versions from MinProtocolVersion to ProtocolVersion.
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+)
 
-type DumpRequest struct {
-	SessionUUID       string
-	LastSegmentNumber uint64
-}
+var (
+	ErrUnsupportedVersion = errors.New("unsupported replication protocol version")
+	ErrUnknownMessageType = errors.New("unknown replication message type")
+	ErrRequestRejected    = errors.New("replication request is rejected")
+)
+
+// Capabilities is a set of Capability flags.
+type Capabilities uint64
 
-type DumpResponse struct {
-	Succeed     bool
-	EndOfDump   bool
-	SegmentData []database.DumpElem
+// supportedCapabilities are advertised by slaves, a master reports the ones it provides.
+var supportedCapabilities = NewCapabilities(
+	Capability_CAPABILITY_WAL_OFFSET,
+	Capability_CAPABILITY_WAL_WAIT,
+	Capability_CAPABILITY_CDC,
+)
+
+func NewCapabilities(capabilities ...Capability) Capabilities {
+	var set Capabilities
+	for _, capability := range capabilities {
+		set |= Capabilities(capability)
+	}
+
+	return set
 }
 
-type WALRequest struct {
-	LastSegmentName string
+func (c Capabilities) Has(capability Capability) bool {
+	return c&Capabilities(capability) != 0
 }
 
-type WALResponse struct {
-	Succeed     bool
-	SegmentName string
-	SegmentData []byte
+func NewHandshakeRequest(capabilities Capabilities) *Request {
+	return &Request{
+		Version:   ProtocolVersion,
+		Type:      MessageType_MESSAGE_TYPE_HANDSHAKE,
+		Handshake: &HandshakeRequest{Capabilities: uint64(capabilities)},
+	}
 }
 
-func NewDumpRequest(sessionUUID string, lastSegmentNumber uint64) Request {
-	return Request{
-		DumpRequest: DumpRequest{
-			SessionUUID:       sessionUUID,
+func NewDumpRequest(sessionUUID string, lastSegmentNumber uint64) *Request {
+	return &Request{
+		Version: ProtocolVersion,
+		Type:    MessageType_MESSAGE_TYPE_DUMP,
+		Dump: &DumpRequest{
+			SessionUuid:       sessionUUID,
 			LastSegmentNumber: lastSegmentNumber,
 		},
 	}
 }
 
-func NewWALRequest(lastSegmentName string) Request {
-	return Request{
-		WALRequest: WALRequest{LastSegmentName: lastSegmentName},
+func NewWALRequest(lastSegmentName string, offset int64, waitTimeout time.Duration) *Request {
+	return &Request{
+		Version: ProtocolVersion,
+		Type:    MessageType_MESSAGE_TYPE_WAL,
+		Wal: &WALRequest{
+			LastSegmentName: lastSegmentName,
+			Offset:          offset,
+			WaitTimeoutMs:   waitTimeout.Milliseconds(),
+		},
+	}
+}
+
+func NewCDCRequest(fromLSN uint64, limit int) *Request {
+	return &Request{
+		Version: ProtocolVersion,
+		Type:    MessageType_MESSAGE_TYPE_CDC,
+		Cdc:     &CDCRequest{FromLsn: fromLSN, Limit: int32(limit)},
 	}
 }
 
-func Encode[ProtocolObject Request | WALResponse | DumpResponse](object *ProtocolObject) ([]byte, error) {
-	var buffer bytes.Buffer
-	encoder := gob.NewEncoder(&buffer)
-	if err := encoder.Encode(object); err != nil {
+func Encode(message proto.Message) ([]byte, error) {
+	data, err := proto.Marshal(message)
+	if err != nil {
 		return nil, fmt.Errorf("failed to encode object: %w", err)
 	}
 
-	return buffer.Bytes(), nil
+	return data, nil
 }
 
-func Decode[ProtocolObject Request | WALResponse | DumpResponse](object *ProtocolObject, data []byte) error {
-	buffer := bytes.NewBuffer(data)
-	decoder := gob.NewDecoder(buffer)
-	if err := decoder.Decode(&object); err != nil {
+func Decode(message proto.Message, data []byte) error {
+	if err := proto.Unmarshal(data, message); err != nil {
 		return fmt.Errorf("failed to decode object: %w", err)
 	}
 
 	return nil
 }
+
+// decodeResponse decodes a response of the master to a request of the message type.
+func decodeResponse(data []byte, messageType MessageType) (*Response, error) {
+	response := &Response{}
+	if err := Decode(response, data); err != nil {
+		return nil, err
+	}
+
+	if response.GetError() != "" {
+		return nil, fmt.Errorf("%w: %s", ErrRequestRejected, response.GetError())
+	}
+
+	if response.GetType() != messageType {
+		return nil, fmt.Errorf("unexpected response %s to %s request", response.GetType(), messageType)
+	}
+
+	return response, nil
+}
+
+func dumpElemsToProto(elems []database.DumpElem) []*DumpElem {
+	messages := make([]*DumpElem, 0, len(elems))
+	for _, elem := range elems {
+		message := &DumpElem{
+			Kind:      uint32(elem.Kind),
+			Key:       elem.Key,
+			BatchSize: elem.BatchSize,
+			Window:    elem.Window,
+			Value:     int64(elem.Value),
+			PrevValue: int64(elem.PrevValue),
+			Mode:      uint32(elem.Mode),
+			TxAt:      uint32(elem.TxAt),
+			Tx:        uint64(elem.Tx),
+			Tat:       elem.TAT,
+		}
+
+		for _, entry := range elem.History {
+			message.History = append(message.History, &HistoryEntry{Start: entry.Start, Value: int64(entry.Value)})
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+func dumpElemsFromProto(messages []*DumpElem) []database.DumpElem {
+	elems := make([]database.DumpElem, 0, len(messages))
+	for _, message := range messages {
+		elem := database.DumpElem{
+			Kind:      database.DumpElemKind(message.GetKind()),
+			Key:       message.GetKey(),
+			BatchSize: message.GetBatchSize(),
+			Window:    message.GetWindow(),
+			Value:     database.ValueType(message.GetValue()),
+			PrevValue: database.ValueType(message.GetPrevValue()),
+			Mode:      database.WindowMode(message.GetMode()),
+			TxAt:      database.TxTime(message.GetTxAt()),
+			Tx:        database.Tx(message.GetTx()),
+			TAT:       message.GetTat(),
+		}
+
+		for _, entry := range message.GetHistory() {
+			elem.History = append(elem.History, database.HistoryEntry{
+				Start: entry.GetStart(),
+				Value: database.ValueType(entry.GetValue()),
+			})
+		}
+
+		elems = append(elems, elem)
+	}
+
+	return elems
+}
+
+func changesToProto(changes []wal.Change) []*Change {
+	messages := make([]*Change, 0, len(changes))
+	for _, change := range changes {
+		messages = append(messages, &Change{
+			Lsn:       change.LSN,
+			Command:   change.Command,
+			Key:       change.Key,
+			Capping:   change.Capping,
+			Timestamp: change.Timestamp,
+			Value:     change.Value,
+		})
+	}
+
+	return messages
+}
+
+func changesFromProto(messages []*Change) []wal.Change {
+	changes := make([]wal.Change, 0, len(messages))
+	for _, message := range messages {
+		changes = append(changes, wal.Change{
+			LSN:       message.GetLsn(),
+			Command:   message.GetCommand(),
+			Key:       message.GetKey(),
+			Capping:   message.GetCapping(),
+			Timestamp: message.GetTimestamp(),
+			Value:     message.GetValue(),
+		})
+	}
+
+	return changes
+}
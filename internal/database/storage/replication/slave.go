@@ -37,10 +37,14 @@ type Slave struct {
 	syncInterval          time.Duration
 	walDirectory          string
 	lastSegmentName       string
-	lastSegmentSize       int64 // Track size of last segment to detect updates
+	lastSegmentSize       int64 // Offset of the next WAL data of the last segment
 	dumpLastSegmentNumber uint64
 	lastAppliedLSN        uint64 // Track last applied LSN to avoid duplicate application
 
+	// Capabilities of the master reported by the handshake, it's repeated after reconnection
+	handshaken   bool
+	capabilities Capabilities
+
 	closeCh     chan struct{}
 	closeDoneCh chan struct{}
 
@@ -172,6 +176,12 @@ func (s *Slave) IsMaster() bool {
 	return false
 }
 
+// LastLSN returns the LSN of the last change received from the master by the dump or the WAL.
+// It must be called after Shutdown.
+func (s *Slave) LastLSN() uint64 {
+	return max(s.dumpLastSegmentNumber, s.lastAppliedLSN)
+}
+
 func (s *Slave) Start(ctx context.Context) {
 	go func() {
 		defer close(s.closeDoneCh)
@@ -220,6 +230,66 @@ func (s *Slave) Start(ctx context.Context) {
 func (s *Slave) Shutdown() {
 	close(s.closeCh)
 	<-s.closeDoneCh
+
+	// the node may change its role and keep running, so the connection is not left to the exit
+	if s.client != nil {
+		if err := s.client.Close(); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to close connection to master")
+		}
+	}
+}
+
+// handshake checks that the master supports the protocol version of the slave and reads its capabilities.
+func (s *Slave) handshake(ctx context.Context) error {
+	if s.handshaken {
+		return nil
+	}
+
+	response, err := s.send(ctx, NewHandshakeRequest(supportedCapabilities))
+	if err != nil {
+		return fmt.Errorf("handshake: %w", err)
+	}
+
+	s.capabilities = Capabilities(response.GetHandshake().GetCapabilities())
+	s.handshaken = true
+
+	s.logger.Info().
+		Uint32("protocol_version", response.GetVersion()).
+		Uint64("capabilities", uint64(s.capabilities)).
+		Msg("replication handshake completed")
+
+	return nil
+}
+
+// send sends a request to the master, the request is sent again after reconnection on network errors.
+func (s *Slave) send(ctx context.Context, request *Request) (*Response, error) {
+	requestData, err := Encode(request)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	responseData, err := s.client.Send(ctx, requestData)
+	if err != nil {
+		if !s.isNetworkError(err) {
+			return nil, fmt.Errorf("send request: %w", err)
+		}
+
+		s.logger.Warn().
+			Err(err).
+			Stringer("message_type", request.GetType()).
+			Msg("network error detected during synchronization, attempting reconnection")
+		if reconnectErr := s.reconnect(ctx); reconnectErr != nil {
+			return nil, fmt.Errorf("reconnection failed: %w", reconnectErr)
+		}
+
+		// Retry after reconnection
+		responseData, err = s.client.Send(ctx, requestData)
+		if err != nil {
+			return nil, fmt.Errorf("send request after reconnection: %w", err)
+		}
+	}
+
+	return decodeResponse(responseData, request.GetType())
 }
 
 // handleSyncError handles synchronization errors with exponential backoff
@@ -237,8 +307,13 @@ func (s *Slave) handleSyncError(err error, syncType string) {
 		s.logger.Error().
 			Int("max_retries", s.maxRetries).
 			Msg("max retries reached, entering wait mode")
-		// Reset counter after long wait
-		time.Sleep(s.maxRetryDelay)
+		// Reset counter after long wait, Shutdown interrupts it, e.g. when the slave is promoted
+		select {
+		case <-time.After(s.maxRetryDelay):
+		case <-s.closeCh:
+			return
+		}
+
 		s.consecutiveErrors = 0
 	}
 }
@@ -246,6 +321,11 @@ func (s *Slave) handleSyncError(err error, syncType string) {
 // getRetryDelay returns delay before next attempt with exponential backoff
 func (s *Slave) getRetryDelay() time.Duration {
 	if s.consecutiveErrors == 0 {
+		// the master holds WAL requests until new data, so they are sent without delay
+		if s.capabilities.Has(Capability_CAPABILITY_WAL_WAIT) {
+			return 0
+		}
+
 		return s.syncInterval
 	}
 
@@ -301,6 +381,7 @@ func (s *Slave) reconnect(ctx context.Context) error {
 		newClient, err := s.clientFactory.Create()
 		if err == nil {
 			s.client = newClient
+			s.handshaken = false
 			s.logger.Info().
 				Int("attempt", attempt+1).
 				Int("max_attempts", maxAttempts).
@@ -9,60 +9,38 @@ import (
 )
 
 func (s *Slave) synchronizeDump(ctx context.Context) error {
-	request := NewDumpRequest(s.sessionUUID, s.dumpLastSegmentNumber)
-
-	requestData, err := Encode(&request)
-	if err != nil {
-		return fmt.Errorf("encode request: %w", err)
+	if err := s.handshake(ctx); err != nil {
+		return err
 	}
 
-	responseData, err := s.client.Send(ctx, requestData)
+	reply, err := s.send(ctx, NewDumpRequest(s.sessionUUID, s.dumpLastSegmentNumber))
 	if err != nil {
-		// Check if it's a network error requiring reconnection
-		if s.isNetworkError(err) {
-			s.logger.Warn().
-				Err(err).
-				Str("session_uuid", s.sessionUUID).
-				Uint64("last_segment_number", s.dumpLastSegmentNumber).
-				Msg("network error detected during dump sync, attempting reconnection")
-			if reconnectErr := s.reconnect(ctx); reconnectErr != nil {
-				return fmt.Errorf("reconnection failed: %w", reconnectErr)
-			}
-			// Retry after reconnection
-			responseData, err = s.client.Send(ctx, requestData)
-			if err != nil {
-				return fmt.Errorf("send request after reconnection: %w", err)
-			}
-		} else {
-			return fmt.Errorf("send request: %w", err)
-		}
+		return err
 	}
 
-	var response DumpResponse
-	if err = Decode(&response, responseData); err != nil {
-		return fmt.Errorf("decode response: %w", err)
-	}
+	response := reply.GetDump()
+	segmentData := dumpElemsFromProto(response.GetSegmentData())
 
-	if response.Succeed {
+	if response.GetSucceed() {
 		wasReadingDump := s.readDump
-		s.readDump = !response.EndOfDump
+		s.readDump = !response.GetEndOfDump()
 
 		// Safe channel send with closed channel check
-		if err := s.sendToDumpStream(response.SegmentData); err != nil {
+		if err := s.sendToDumpStream(segmentData); err != nil {
 			return fmt.Errorf("failed to send dump data to stream: %w", err)
 		}
 
-		if len(response.SegmentData) > 0 {
-			s.dumpLastSegmentNumber = maxLSN(response.SegmentData)
+		if len(segmentData) > 0 {
+			s.dumpLastSegmentNumber = maxLSN(segmentData)
 		}
 
 		// If dump is complete (EndOfDump = true), mark it as applied
 		// The actual application happens in engine's dumpStream handler
-		if wasReadingDump && response.EndOfDump {
+		if wasReadingDump && response.GetEndOfDump() {
 			s.logger.Info().
 				Str("session_uuid", s.sessionUUID).
 				Uint64("last_segment_number", s.dumpLastSegmentNumber).
-				Int("last_batch_size", len(response.SegmentData)).
+				Int("last_batch_size", len(segmentData)).
 				Msg("dump synchronization completed, waiting for engine to apply")
 			// Give engine some time to process the last batch
 			// In a real implementation, we'd wait for confirmation from engine
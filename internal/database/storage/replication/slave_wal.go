@@ -12,43 +12,23 @@ import (
 )
 
 func (s *Slave) synchronizeWAL(ctx context.Context) error {
-	request := NewWALRequest(s.lastSegmentName)
-
-	requestData, err := Encode(&request)
-	if err != nil {
-		return fmt.Errorf("encode wal request: %w", err)
+	if err := s.handshake(ctx); err != nil {
+		return err
 	}
 
-	responseData, err := s.client.Send(ctx, requestData)
-	if err != nil {
-		// Check if it's a network error requiring reconnection
-		if s.isNetworkError(err) {
-			s.logger.Warn().
-				Err(err).
-				Str("last_segment_name", s.lastSegmentName).
-				Uint64("dump_last_segment_number", s.dumpLastSegmentNumber).
-				Msg("network error detected during WAL sync, attempting reconnection")
-			if reconnectErr := s.reconnect(ctx); reconnectErr != nil {
-				return fmt.Errorf("reconnection failed: %w", reconnectErr)
-			}
-			// Retry after reconnection
-			responseData, err = s.client.Send(ctx, requestData)
-			if err != nil {
-				return fmt.Errorf("send wal request after reconnection: %w", err)
-			}
-		} else {
-			return fmt.Errorf("send wal request: %w", err)
-		}
+	// a master which doesn't hold requests is polled every sync interval instead
+	var waitTimeout time.Duration
+	if s.capabilities.Has(Capability_CAPABILITY_WAL_WAIT) {
+		waitTimeout = s.syncInterval
 	}
 
-	var response WALResponse
-	if err = Decode(&response, responseData); err != nil {
-		return fmt.Errorf("decode wal response: %w", err)
+	reply, err := s.send(ctx, NewWALRequest(s.lastSegmentName, s.lastSegmentSize, waitTimeout))
+	if err != nil {
+		return err
 	}
 
-	if response.Succeed {
-		err = s.handleResponse(ctx, response)
-		if err != nil {
+	if response := reply.GetWal(); response.GetSucceed() {
+		if err := s.handleResponse(ctx, response); err != nil {
 			return fmt.Errorf("handle wal response: %w", err)
 		}
 
@@ -58,8 +38,8 @@ func (s *Slave) synchronizeWAL(ctx context.Context) error {
 	return fmt.Errorf("failed to apply replication data: master error")
 }
 
-func (s *Slave) handleResponse(ctx context.Context, response WALResponse) error {
-	if response.SegmentName == "" {
+func (s *Slave) handleResponse(ctx context.Context, response *WALResponse) error {
+	if response.GetSegmentName() == "" {
 		s.logger.Debug().
 			Str("last_segment_name", s.lastSegmentName).
 			Uint64("dump_last_segment_number", s.dumpLastSegmentNumber).
@@ -68,55 +48,58 @@ func (s *Slave) handleResponse(ctx context.Context, response WALResponse) error
 		return nil
 	}
 
-	filename := response.SegmentName
+	filename := response.GetSegmentName()
 	isSameSegment := filename == s.lastSegmentName
-	segmentSize := int64(len(response.SegmentData))
+	dataSize := int64(len(response.GetSegmentData()))
 
-	// If it's the same segment, check if it has new data
-	if isSameSegment && segmentSize <= s.lastSegmentSize {
-		s.logger.Debug().
-			Str("segment_name", filename).
-			Int64("segment_size", segmentSize).
-			Int64("last_segment_size", s.lastSegmentSize).
-			Msg("segment has no new data, skipping")
-		return nil
+	// Data of the same segment must follow the received data, otherwise the segment is received again
+	if response.GetOffset() != 0 && (!isSameSegment || response.GetOffset() != s.lastSegmentSize) {
+		s.lastSegmentSize = 0
+
+		return fmt.Errorf("unexpected WAL data offset %d of segment %s (received %d of %s)",
+			response.GetOffset(), filename, s.lastSegmentSize, s.lastSegmentName)
 	}
 
 	s.logger.Info().
 		Str("segment_name", filename).
-		Int64("segment_size", segmentSize).
+		Int64("offset", response.GetOffset()).
+		Int64("data_size", dataSize).
 		Str("last_segment_name", s.lastSegmentName).
-		Int64("last_segment_size", s.lastSegmentSize).
 		Uint64("dump_last_segment_number", s.dumpLastSegmentNumber).
 		Bool("is_same_segment", isSameSegment).
-		Msg("received WAL segment from master")
+		Msg("received WAL data from master")
 
-	// Save segment (overwrite if same segment with new data)
-	if err := s.saveWALSegment(filename, response.SegmentData); err != nil {
+	if err := s.saveWALSegment(filename, response.GetOffset(), response.GetSegmentData()); err != nil {
 		return fmt.Errorf("save wal segment: %w", err)
 	}
 
 	// Apply only new logs (filter by LSN)
-	if err := s.applyDataToEngine(ctx, response.SegmentData, response.SegmentName); err != nil {
+	if err := s.applyDataToEngine(ctx, response.GetSegmentData(), response.GetSegmentName()); err != nil {
 		return fmt.Errorf("apply data to engine segment: %w", err)
 	}
 
-	// Update last segment name and size
-	s.lastSegmentName = response.SegmentName
-	s.lastSegmentSize = segmentSize
+	// Update last segment name and the offset of the next data
+	s.lastSegmentName = response.GetSegmentName()
+	s.lastSegmentSize = response.GetOffset() + dataSize
 
 	return nil
 }
 
-func (s *Slave) saveWALSegment(segmentName string, segmentData []byte) error {
+// saveWALSegment appends the data to the segment at the offset, data at offset 0 replaces the segment.
+func (s *Slave) saveWALSegment(segmentName string, offset int64, segmentData []byte) error {
 	flags := os.O_CREATE | os.O_WRONLY
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+
 	filename := filepath.Join(s.walDirectory, segmentName)
 	segment, err := os.OpenFile(filename, flags, 0o644)
 	if err != nil {
 		return fmt.Errorf("failed to create wal segment: %w", err)
 	}
+	defer segment.Close()
 
-	if _, err = segment.Write(segmentData); err != nil {
+	if _, err = segment.WriteAt(segmentData, offset); err != nil {
 		return fmt.Errorf("failed to write data to segment: %w", err)
 	}
 
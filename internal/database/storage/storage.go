@@ -3,21 +3,36 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
 
 	"fq/internal/database"
+	"fq/internal/database/compute"
 	"fq/internal/tools"
 )
 
 type Engine interface {
 	Incr(database.TxContext, database.BatchKey) database.ValueType
+	IncrBy(database.TxContext, database.BatchKey, database.ValueType) (database.ValueType, error)
+	CapIncr(database.TxContext, database.BatchKey, database.ValueType) (database.ValueType, bool)
+	MCapIncr(database.TxContext, []database.BatchKey, []database.ValueType) ([]database.ValueType, bool)
+	Throttle(database.TxContext, database.ThrottleKey, int64) database.ThrottleResult
 	Get(database.BatchKey) (database.ValueType, bool)
+	History(database.BatchKey, int) ([]database.HistoryEntry, error)
+	Watch(database.BatchKey) (<-chan struct{}, func())
+	SubscribeThreshold(string, database.Window, database.ValueType) (<-chan database.ThresholdEvent, func(), error)
+	SetPolicy(database.Policy) error
+	GetPolicy(string) (database.Policy, bool)
+	DelPolicy(string) (bool, error)
 	Del(database.TxContext, database.BatchKey) bool
 	MDel(database.TxContext, []database.BatchKey) []bool
+	MIncr(database.TxContext, []database.BatchKey) []database.ValueType
 	Clean(context.Context)
+	Reset()
 	Dump(context.Context, database.Tx) (<-chan database.DumpElem, <-chan error)
 	RestoreDumpElem(context.Context, database.DumpElem) error
 }
@@ -25,9 +40,55 @@ type Engine interface {
 type WAL interface {
 	Start()
 	Shutdown()
-	Incr(ctx context.Context, txCtx database.TxContext, key database.BatchKey) tools.FutureError
-	Del(ctx context.Context, txCtx database.TxContext, key database.BatchKey) tools.FutureError
-	MDel(ctx context.Context, txCtx database.TxContext, keys []database.BatchKey) tools.FutureError
+	Incr(ctx context.Context, txCtx database.TxContext, key database.BatchKey, value database.ValueType) tools.FutureError
+	IncrBy(
+		ctx context.Context,
+		txCtx database.TxContext,
+		key database.BatchKey,
+		delta database.ValueType,
+		value database.ValueType,
+	) tools.FutureError
+	CapIncr(
+		ctx context.Context,
+		txCtx database.TxContext,
+		key database.BatchKey,
+		limit database.ValueType,
+		value database.ValueType,
+		allowed bool,
+	) tools.FutureError
+	MCapIncr(
+		ctx context.Context,
+		txCtx database.TxContext,
+		keys []database.BatchKey,
+		limits []database.ValueType,
+		values []database.ValueType,
+		allowed bool,
+	) tools.FutureError
+	Throttle(
+		ctx context.Context,
+		txCtx database.TxContext,
+		key database.ThrottleKey,
+		now int64,
+		allowed bool,
+		tat int64,
+	) tools.FutureError
+	SetPolicy(ctx context.Context, txCtx database.TxContext, policy database.Policy) tools.FutureError
+	DelPolicy(ctx context.Context, txCtx database.TxContext, name string) tools.FutureError
+	Del(ctx context.Context, txCtx database.TxContext, key database.BatchKey, deleted bool) tools.FutureError
+	MDel(ctx context.Context, txCtx database.TxContext, keys []database.BatchKey, deleted []bool) tools.FutureError
+	MIncr(
+		ctx context.Context,
+		txCtx database.TxContext,
+		keys []database.BatchKey,
+		values []database.ValueType,
+	) tools.FutureError
+	Append(
+		ctx context.Context,
+		txCtx database.TxContext,
+		commandID compute.CommandID,
+		args []string,
+		results []int64,
+	) tools.FutureError
 	TryRecoverWALSegments(ctx context.Context, dumpLastLSN uint64) (lastLSN uint64, err error)
 }
 
@@ -41,6 +102,16 @@ type Replica interface {
 	Shutdown()
 }
 
+// SwitchableReplica is a replica whose role can be changed at runtime.
+type SwitchableReplica interface {
+	Replica
+	// Promote makes a slave a master and returns the LSN of the last change of the old master
+	Promote() (lastLSN uint64, err error)
+	// ReplicaOf makes the node a slave of the master, reset is called after the old role stops
+	// and before the slave synchronizes the dump of the master
+	ReplicaOf(masterAddress string, reset func()) error
+}
+
 type Storage struct {
 	engine        Engine
 	wal           WAL
@@ -51,6 +122,12 @@ type Storage struct {
 	dumpInterval  time.Duration
 	syncCommit    bool
 
+	// role is held by writes and locked by changes of the replication role
+	role sync.RWMutex
+	keys keyLocks
+	// log is held while a write takes its transaction and pushes its WAL record
+	log sync.Mutex
+
 	tx     atomic.Uint64
 	dumpTx atomic.Uint64
 }
@@ -85,6 +162,76 @@ func NewStorage(
 	}, nil
 }
 
+// IsReadOnly reports whether the storage is a slave replica, which only applies changes of the master.
+func (s *Storage) IsReadOnly() bool {
+	return s.replica != nil && !s.replica.IsMaster()
+}
+
+// Promote makes a slave a master: the WAL writer starts and transactions continue from the last change
+// of the old master. It returns the last transaction of the node, a master is not changed.
+func (s *Storage) Promote(_ context.Context) (database.Tx, error) {
+	replica, ok := s.replica.(SwitchableReplica)
+	if !ok || s.wal == nil {
+		return 0, database.ErrReplicationDisabled
+	}
+
+	s.role.Lock()
+	defer s.role.Unlock()
+
+	if replica.IsMaster() {
+		return database.Tx(s.tx.Load()), nil
+	}
+
+	lastLSN, err := replica.Promote()
+	if err != nil {
+		return 0, err
+	}
+
+	if lastLSN > s.tx.Load() {
+		s.tx.Store(lastLSN)
+	}
+
+	s.wal.Start()
+
+	return database.Tx(s.tx.Load()), nil
+}
+
+// ReplicaOf makes the node a slave of the master. Writes of a master are flushed to the WAL
+// before its writer stops, a master which can't be reached doesn't change the role.
+// The history of the node may diverge from the history of the master, so its state is reset
+// and resynchronized from the dump and the WAL of the master.
+func (s *Storage) ReplicaOf(_ context.Context, masterAddress string) error {
+	replica, ok := s.replica.(SwitchableReplica)
+	if !ok || s.wal == nil {
+		return database.ErrReplicationDisabled
+	}
+
+	s.role.Lock()
+	defer s.role.Unlock()
+
+	wasMaster := replica.IsMaster()
+	if wasMaster {
+		s.wal.Shutdown()
+	}
+
+	if err := replica.ReplicaOf(masterAddress, s.reset); err != nil {
+		if wasMaster {
+			s.wal.Start()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// reset removes the state of the node before it is replaced by the state of a master.
+func (s *Storage) reset() {
+	s.engine.Reset()
+	s.tx.Store(0)
+	s.dumpTx.Store(0)
+}
+
 func (s *Storage) LoadWAL(ctx context.Context, dumpLastTx database.Tx) error {
 	if s.wal == nil {
 		return nil
@@ -128,6 +275,10 @@ func (s *Storage) Shutdown() {
 	go func() {
 		defer close(shutdownDone)
 
+		// the role must not change while the replica and the WAL stop
+		s.role.Lock()
+		defer s.role.Unlock()
+
 		// Shutdown replica first (slave needs to stop before channels are closed)
 		if s.replica != nil {
 			s.replica.Shutdown()
@@ -153,95 +304,485 @@ func (s *Storage) Shutdown() {
 	}
 }
 
+// Incr increments the counter of the key. Like other counter writes it is logged before
+// it is applied: the result is computed by a dry run of the engine and carried by the WAL record
+// for the change stream, the keys are locked until the write is applied, so the result stays valid.
+// The keys are locked before the write takes its transaction, so writes of a key get ascending LSNs
+// and reach the WAL and the engine in the same order.
+// A write which failed to reach the WAL with sync commit is not applied, unless it is a write
+// of a pipeline, which is applied before its commit group is waited for.
 func (s *Storage) Incr(ctx context.Context, key database.BatchKey) (database.ValueType, error) {
-	txCtx := s.makeTxContext()
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
 
-	if s.wal != nil {
-		future := s.wal.Incr(ctx, txCtx, key)
-		if s.syncCommit {
-			if err := future.Get(); err != nil {
-				return 0, err
-			}
-		}
+	if s.wal == nil {
+		return s.engine.Incr(s.makeTxContext(ctx), key), nil
+	}
+
+	defer s.keys.lock(key)()
+
+	txCtx := makeWriteContext(ctx)
+	value := s.engine.Incr(dryRun(txCtx), key)
+	if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+		return s.wal.Incr(ctx, txCtx, key, value)
+	}); err != nil {
+		return 0, err
 	}
 
 	return s.engine.Incr(txCtx, key), nil
 }
 
+func (s *Storage) IncrBy(
+	ctx context.Context,
+	key database.BatchKey,
+	delta database.ValueType,
+) (database.ValueType, error) {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if s.wal == nil {
+		return s.engine.IncrBy(s.makeTxContext(ctx), key, delta)
+	}
+
+	defer s.keys.lock(key)()
+
+	txCtx := makeWriteContext(ctx)
+	value, err := s.engine.IncrBy(dryRun(txCtx), key, delta)
+	if err != nil {
+		return value, err
+	}
+
+	if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+		return s.wal.IncrBy(ctx, txCtx, key, delta, value)
+	}); err != nil {
+		return 0, err
+	}
+
+	return s.engine.IncrBy(txCtx, key, delta)
+}
+
+func (s *Storage) CapIncr(
+	ctx context.Context,
+	key database.BatchKey,
+	limit database.ValueType,
+) (database.ValueType, bool, error) {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return 0, false, err
+	}
+	defer unlock()
+
+	if s.wal == nil {
+		value, allowed := s.engine.CapIncr(s.makeTxContext(ctx), key, limit)
+
+		return value, allowed, nil
+	}
+
+	defer s.keys.lock(key)()
+
+	txCtx := makeWriteContext(ctx)
+	value, allowed := s.engine.CapIncr(dryRun(txCtx), key, limit)
+	if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+		return s.wal.CapIncr(ctx, txCtx, key, limit, value, allowed)
+	}); err != nil {
+		return 0, false, err
+	}
+
+	value, allowed = s.engine.CapIncr(txCtx, key, limit)
+
+	return value, allowed, nil
+}
+
+func (s *Storage) MCapIncr(
+	ctx context.Context,
+	keys []database.BatchKey,
+	limits []database.ValueType,
+) ([]database.ValueType, bool, error) {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return nil, false, err
+	}
+	defer unlock()
+
+	if s.wal == nil {
+		values, allowed := s.engine.MCapIncr(s.makeTxContext(ctx), keys, limits)
+
+		return values, allowed, nil
+	}
+
+	defer s.keys.lock(keys...)()
+
+	txCtx := makeWriteContext(ctx)
+	values, allowed := s.engine.MCapIncr(dryRun(txCtx), keys, limits)
+	if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+		return s.wal.MCapIncr(ctx, txCtx, keys, limits, values, allowed)
+	}); err != nil {
+		return nil, false, err
+	}
+
+	values, allowed = s.engine.MCapIncr(txCtx, keys, limits)
+
+	return values, allowed, nil
+}
+
+// Throttle applies the rate limit of the key. Like counter writes it is logged before it is applied,
+// the key is locked before the moment of the request is taken, so requests of the key reach
+// the WAL and the engine in the same order.
+func (s *Storage) Throttle(ctx context.Context, key database.ThrottleKey) (database.ThrottleResult, error) {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return database.ThrottleResult{}, err
+	}
+	defer unlock()
+
+	if s.wal == nil {
+		return s.engine.Throttle(s.makeTxContext(ctx), key, time.Now().UnixNano()), nil
+	}
+
+	defer s.keys.lockNames(key.Key)()
+
+	txCtx := makeWriteContext(ctx)
+	now := time.Now().UnixNano()
+
+	// the theoretical arrival time of the key after the request is logged for replicas
+	result := s.engine.Throttle(dryRun(txCtx), key, now)
+	tat := now + int64(result.ResetAfter)
+	if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+		return s.wal.Throttle(ctx, txCtx, key, now, result.Allowed, tat)
+	}); err != nil {
+		return database.ThrottleResult{}, err
+	}
+
+	return s.engine.Throttle(txCtx, key, now), nil
+}
+
 func (s *Storage) Get(_ context.Context, key database.BatchKey) (database.ValueType, error) {
 	value, _ := s.engine.Get(key)
 
 	return value, nil
 }
 
-func (s *Storage) Del(ctx context.Context, key database.BatchKey) (bool, error) {
-	txCtx := s.makeTxContext()
+func (s *Storage) MGet(_ context.Context, keys []database.BatchKey) ([]database.ValueType, error) {
+	values := make([]database.ValueType, len(keys))
+	for i, key := range keys {
+		values[i], _ = s.engine.Get(key)
+	}
+
+	return values, nil
+}
+
+func (s *Storage) History(_ context.Context, key database.BatchKey, n int) ([]database.HistoryEntry, error) {
+	return s.engine.History(key, n)
+}
+
+func (s *Storage) SetPolicy(ctx context.Context, policy database.Policy) error {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// the policy is locked like keys, so changes of a policy are applied in the order of the WAL
+	defer s.keys.lockNames(policy.Name)()
+
+	// static policies are checked before writing to the WAL, so the record is not rejected on replay
+	if current, ok := s.engine.GetPolicy(policy.Name); ok && current.Static {
+		return database.ErrPolicyReadOnly
+	}
 
 	if s.wal != nil {
-		future := s.wal.Del(ctx, txCtx, key)
-		if s.syncCommit {
-			if err := future.Get(); err != nil {
-				return false, err
-			}
+		txCtx := makeWriteContext(ctx)
+		if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+			return s.wal.SetPolicy(ctx, txCtx, policy)
+		}); err != nil {
+			return err
 		}
 	}
 
-	return s.engine.Del(txCtx, key), nil
+	return s.engine.SetPolicy(policy)
 }
 
-func (s *Storage) MDel(ctx context.Context, keys []database.BatchKey) ([]bool, error) {
-	txCtx := s.makeTxContext()
+func (s *Storage) GetPolicy(_ context.Context, name string) (database.Policy, error) {
+	policy, ok := s.engine.GetPolicy(name)
+	if !ok {
+		return database.Policy{}, database.ErrPolicyNotFound
+	}
+
+	return policy, nil
+}
+
+func (s *Storage) DelPolicy(ctx context.Context, name string) (bool, error) {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	defer s.keys.lockNames(name)()
+
+	current, ok := s.engine.GetPolicy(name)
+	if !ok {
+		return false, nil
+	}
+
+	if current.Static {
+		return false, database.ErrPolicyReadOnly
+	}
 
 	if s.wal != nil {
-		future := s.wal.MDel(ctx, txCtx, keys)
-		if s.syncCommit {
-			if err := future.Get(); err != nil {
-				return nil, err
-			}
+		txCtx := makeWriteContext(ctx)
+		if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+			return s.wal.DelPolicy(ctx, txCtx, name)
+		}); err != nil {
+			return false, err
 		}
 	}
 
+	return s.engine.DelPolicy(name)
+}
+
+func (s *Storage) Del(ctx context.Context, key database.BatchKey) (bool, error) {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	if s.wal == nil {
+		return s.engine.Del(s.makeTxContext(ctx), key), nil
+	}
+
+	defer s.keys.lock(key)()
+
+	txCtx := makeWriteContext(ctx)
+	deleted := s.engine.Del(dryRun(txCtx), key)
+	if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+		return s.wal.Del(ctx, txCtx, key, deleted)
+	}); err != nil {
+		return false, err
+	}
+
+	return s.engine.Del(txCtx, key), nil
+}
+
+func (s *Storage) MDel(ctx context.Context, keys []database.BatchKey) ([]bool, error) {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if s.wal == nil {
+		return s.engine.MDel(s.makeTxContext(ctx), keys), nil
+	}
+
+	defer s.keys.lock(keys...)()
+
+	txCtx := makeWriteContext(ctx)
+	deleted := s.engine.MDel(dryRun(txCtx), keys)
+	if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+		return s.wal.MDel(ctx, txCtx, keys, deleted)
+	}); err != nil {
+		return nil, err
+	}
+
 	return s.engine.MDel(txCtx, keys), nil
 }
 
+func (s *Storage) MIncr(ctx context.Context, keys []database.BatchKey) ([]database.ValueType, error) {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if s.wal == nil {
+		return s.engine.MIncr(s.makeTxContext(ctx), keys), nil
+	}
+
+	defer s.keys.lock(keys...)()
+
+	txCtx := makeWriteContext(ctx)
+	values := s.engine.MIncr(dryRun(txCtx), keys)
+	if err := s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+		return s.wal.MIncr(ctx, txCtx, keys, values)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.engine.MIncr(txCtx, keys), nil
+}
+
+// Log writes a WAL record of a registered command, which has already changed its state, see database.Database.LogQuery.
+// The record is replayed on recovery and on slaves by the compute.Command.Replay of the command.
+func (s *Storage) Log(ctx context.Context, commandID compute.CommandID, args []string, results []int64) error {
+	unlock, err := s.lockWrite()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+
+	txCtx := makeWriteContext(ctx)
+
+	return s.logWrite(ctx, &txCtx, func(txCtx database.TxContext) tools.FutureError {
+		return s.wal.Append(ctx, txCtx, commandID, args, results)
+	})
+}
+
+// Watch waits until the key value changes. Watchers are woken by writes to the key
+// and by the end of the current window, when the value is reset.
 func (s *Storage) Watch(ctx context.Context, key database.BatchKey) (database.ValueType, error) {
-	// Get initial value
 	lastValue, err := s.Get(ctx, key)
 	if err != nil {
 		return 0, err
 	}
 
-	// Poll for changes every 100ms
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
 	for {
+		// register before reading the value, so a change between them is not missed
+		changed, cancel := s.engine.Watch(key)
+
+		currentValue, err := s.Get(ctx, key)
+		if err != nil {
+			cancel()
+
+			return 0, err
+		}
+
+		if currentValue != lastValue {
+			cancel()
+
+			return currentValue, nil
+		}
+
+		now := time.Now()
+		windowEnd := time.Unix(key.Window.End(now.Unix()), 0)
+		timer := time.NewTimer(windowEnd.Sub(now))
+
 		select {
 		case <-ctx.Done():
-			return 0, ctx.Err()
-		case <-ticker.C:
-			currentValue, err := s.Get(ctx, key)
-			if err != nil {
-				return 0, err
-			}
+			timer.Stop()
+			cancel()
 
-			// If value changed, return it
-			if currentValue != lastValue {
-				return currentValue, nil
-			}
+			return 0, ctx.Err()
+		case <-changed:
+		case <-timer.C:
 		}
+
+		timer.Stop()
+		cancel()
 	}
 }
 
-func (s *Storage) makeTxContext() database.TxContext {
+// SubscribeThreshold subscribes to writes making counters of keys matching the pattern reach the limit.
+// cancel must be called when the subscription is not needed anymore.
+func (s *Storage) SubscribeThreshold(
+	_ context.Context,
+	pattern string,
+	window database.Window,
+	limit database.ValueType,
+) (events <-chan database.ThresholdEvent, cancel func(), err error) {
+	return s.engine.SubscribeThreshold(pattern, window, limit)
+}
+
+// commit waits for the WAL write with sync commit. Writes of a pipeline are added
+// to its commit group instead, so the pipeline waits for them at once.
+func (s *Storage) commit(ctx context.Context, future tools.FutureError) error {
+	if !s.syncCommit {
+		return nil
+	}
+
+	if group, ok := database.CommitGroupFromContext(ctx); ok {
+		group.Add(future)
+
+		return nil
+	}
+
+	if err := future.Get(); err != nil {
+		return notDurable(err)
+	}
+
+	return nil
+}
+
+// lockWrite holds the replication role during a write, writes of slaves are rejected.
+func (s *Storage) lockWrite() (func(), error) {
+	s.role.RLock()
+	if s.IsReadOnly() {
+		s.role.RUnlock()
+
+		return nil, database.ErrReadOnlyReplica
+	}
+
+	return s.role.RUnlock, nil
+}
+
+// notDurable marks an error of writing to the WAL.
+func notDurable(err error) error {
+	return fmt.Errorf("%w: %w", database.ErrNotDurable, err)
+}
+
+// dryRun makes the engine compute the result of a write without applying it.
+func dryRun(txCtx database.TxContext) database.TxContext {
+	txCtx.DryRun = true
+
+	return txCtx
+}
+
+// makeTxContext assigns the next transaction to a write which isn't logged.
+func (s *Storage) makeTxContext(ctx context.Context) database.TxContext {
+	txCtx := makeWriteContext(ctx)
+	s.assignTx(&txCtx)
+
+	return txCtx
+}
+
+// makeWriteContext makes the context of a write without a transaction, logWrite assigns it.
+// It uses the explicit time of the event from ctx if it is set.
+func makeWriteContext(ctx context.Context) database.TxContext {
+	currTime, ok := database.TxTimeFromContext(ctx)
+	if !ok {
+		currTime = database.TxTime(time.Now().Unix())
+	}
+
 	return database.TxContext{
-		Tx:       database.Tx(s.tx.Add(1)),
-		DumpTx:   database.Tx(s.dumpTx.Load()),
-		CurrTime: database.TxTime(time.Now().Unix()),
+		CurrTime: currTime,
 		FromWAL:  false,
 	}
 }
 
+// assignTx assigns the next transaction to a write.
+func (s *Storage) assignTx(txCtx *database.TxContext) {
+	txCtx.Tx = database.Tx(s.tx.Add(1))
+	txCtx.DumpTx = database.Tx(s.dumpTx.Load())
+}
+
+// logWrite assigns the next transaction to a write and pushes its record to the WAL under one lock,
+// so records reach the WAL in the order of their LSNs, which slaves and change streams rely on.
+// The keys of the write must be locked by the caller until it is applied.
+func (s *Storage) logWrite(
+	ctx context.Context,
+	txCtx *database.TxContext,
+	push func(database.TxContext) tools.FutureError,
+) error {
+	var future tools.FutureError
+	tools.WithLock(&s.log, func() {
+		s.assignTx(txCtx)
+		future = push(*txCtx)
+	})
+
+	return s.commit(ctx, future)
+}
+
 func (s *Storage) gcLoop(ctx context.Context) {
 	t := time.NewTicker(s.cleanInterval)
 	defer t.Stop()
@@ -0,0 +1,83 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+	"fq/internal/database/storage"
+	inmemory "fq/internal/database/storage/engine/in-memory"
+	"fq/internal/database/storage/wal"
+)
+
+func TestStorage_ConcurrentWritesKeepWALOrder(t *testing.T) {
+	logger := zerolog.Nop()
+	engine, err := inmemory.NewEngine(inmemory.HashTableBuilder, 4, &logger, nil, nil)
+	require.NoError(t, err)
+
+	directory := t.TempDir()
+	fsReader := wal.NewFSReader(directory, &logger)
+	writeAheadLog := wal.NewWAL(
+		wal.NewFSWriter(directory, 1<<30, &logger),
+		fsReader,
+		nil,
+		time.Millisecond,
+		16,
+		directory,
+		&logger,
+	)
+
+	strg, err := storage.NewStorage(engine, writeAheadLog, nil, nil, &logger, time.Minute, time.Minute, false)
+	require.NoError(t, err)
+	writeAheadLog.Start()
+
+	key := database.BatchKey{Key: "key", Window: database.NewSecondsWindow(60), BatchSizeStr: "60"}
+
+	const writers, writes = 8, 200
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < writes; j++ {
+				if j%2 == 0 {
+					_, err := strg.Incr(context.Background(), key)
+					require.NoError(t, err)
+				} else {
+					_, err := strg.MIncr(context.Background(), []database.BatchKey{key})
+					require.NoError(t, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	writeAheadLog.Shutdown()
+
+	// segments are read in the order they are written, records aren't sorted by LSN
+	segments, err := filepath.Glob(filepath.Join(directory, "wal_*.log"))
+	require.NoError(t, err)
+
+	var logs []*wal.LogData
+	for _, segment := range segments {
+		segmentLogs, err := fsReader.ReadSegment(context.Background(), segment)
+		require.NoError(t, err)
+
+		logs = append(logs, segmentLogs...)
+	}
+
+	require.Len(t, logs, writers*writes)
+	for i, log := range logs {
+		// the records of the key follow each other in the order of LSNs and of the counter
+		require.Equal(t, uint64(i+1), log.LSN)
+		require.Equal(t, []int64{int64(i + 1)}, log.Results)
+	}
+}
@@ -0,0 +1,157 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"fq/internal/database/compute"
+)
+
+var (
+	ErrLSNTooOld     = errors.New("LSN too old")
+	ErrInvalidWALLog = errors.New("invalid WAL log")
+)
+
+// Change is a change of a counter decoded from a WAL record.
+type Change struct {
+	LSN       uint64 `json:"lsn"`
+	Command   string `json:"command"`
+	Key       string `json:"key"`
+	Capping   string `json:"capping"`
+	Timestamp int64  `json:"timestamp"` // unix time of the event
+	Value     int64  `json:"value"`     // counter value after the change, 0 for a deleted key
+}
+
+// DecodeChanges returns counter changes of a WAL record. Records which didn't change counters
+// (a denied CAPINCR, a DEL of a missing key), records of other commands and records written
+// before results were logged produce no changes.
+func DecodeChanges(log *LogData) ([]Change, error) {
+	if len(log.Results) == 0 {
+		return nil, nil
+	}
+
+	commandID := compute.CommandID(log.CommandId)
+	args, results := log.Arguments, log.Results
+
+	switch commandID {
+	case compute.IncrCommandID, compute.IncrByCommandID:
+		if len(args) < 3 {
+			return nil, fmt.Errorf("%w: lsn %d", ErrInvalidWALLog, log.LSN)
+		}
+
+		change, err := makeChange(log.LSN, commandID, args[0], args[1], args[2], results[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return []Change{change}, nil
+	case compute.CapIncrCommandID:
+		if len(args) < 3 || len(results) != 2 {
+			return nil, fmt.Errorf("%w: lsn %d", ErrInvalidWALLog, log.LSN)
+		}
+
+		if results[1] == 0 {
+			return nil, nil
+		}
+
+		change, err := makeChange(log.LSN, commandID, args[0], args[1], args[2], results[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return []Change{change}, nil
+	case compute.MCapIncrCommandID:
+		keysNumber := (len(args) - 1) / 3
+		if len(args) < 4 || len(results) != keysNumber+1 {
+			return nil, fmt.Errorf("%w: lsn %d", ErrInvalidWALLog, log.LSN)
+		}
+
+		if results[keysNumber] == 0 {
+			return nil, nil
+		}
+
+		changes := make([]Change, 0, keysNumber)
+		for i := 0; i < keysNumber; i++ {
+			change, err := makeChange(log.LSN, commandID, args[1+i*3], args[2+i*3], args[0], results[i])
+			if err != nil {
+				return nil, err
+			}
+
+			changes = append(changes, change)
+		}
+
+		return changes, nil
+	case compute.DelCommandID:
+		if len(args) < 3 || len(results) != 1 {
+			return nil, fmt.Errorf("%w: lsn %d", ErrInvalidWALLog, log.LSN)
+		}
+
+		if results[0] == 0 {
+			return nil, nil
+		}
+
+		change, err := makeChange(log.LSN, commandID, args[0], args[1], args[2], 0)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Change{change}, nil
+	case compute.MDelCommandID:
+		keysNumber := (len(args) - 1) / 2
+		if len(args) < 3 || len(results) != keysNumber {
+			return nil, fmt.Errorf("%w: lsn %d", ErrInvalidWALLog, log.LSN)
+		}
+
+		changes := make([]Change, 0, keysNumber)
+		for i := 0; i < keysNumber; i++ {
+			if results[i] == 0 {
+				continue
+			}
+
+			change, err := makeChange(log.LSN, commandID, args[1+i*2], args[2+i*2], args[0], 0)
+			if err != nil {
+				return nil, err
+			}
+
+			changes = append(changes, change)
+		}
+
+		return changes, nil
+	case compute.MIncrCommandID:
+		keysNumber := (len(args) - 1) / 2
+		if len(args) < 3 || len(results) != keysNumber {
+			return nil, fmt.Errorf("%w: lsn %d", ErrInvalidWALLog, log.LSN)
+		}
+
+		changes := make([]Change, 0, keysNumber)
+		for i := 0; i < keysNumber; i++ {
+			change, err := makeChange(log.LSN, commandID, args[1+i*2], args[2+i*2], args[0], results[i])
+			if err != nil {
+				return nil, err
+			}
+
+			changes = append(changes, change)
+		}
+
+		return changes, nil
+	default:
+		return nil, nil
+	}
+}
+
+func makeChange(lsn uint64, commandID compute.CommandID, key, capping, currTimeStr string, value int64) (Change, error) {
+	currTime, err := strconv.ParseInt(currTimeStr, 16, 64)
+	if err != nil {
+		return Change{}, fmt.Errorf("%w: lsn %d: parse curr time: %w", ErrInvalidWALLog, lsn, err)
+	}
+
+	return Change{
+		LSN:       lsn,
+		Command:   compute.CommandIDToCommandName(commandID),
+		Key:       key,
+		Capping:   capping,
+		Timestamp: currTime,
+		Value:     value,
+	}, nil
+}
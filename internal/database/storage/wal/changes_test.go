@@ -0,0 +1,192 @@
+package wal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database/compute"
+)
+
+func TestDecodeChanges(t *testing.T) {
+	tests := map[string]struct {
+		log     *LogData
+		changes []Change
+		err     error
+	}{
+		"incr": {
+			log: &LogData{LSN: 1, CommandId: uint32(compute.IncrCommandID), Arguments: []string{"key", "60", "a", "SLIDING"}, Results: []int64{3}},
+			changes: []Change{
+				{LSN: 1, Command: "INCR", Key: "key", Capping: "60", Timestamp: 10, Value: 3},
+			},
+		},
+		"allowed capincr": {
+			log: &LogData{LSN: 2, CommandId: uint32(compute.CapIncrCommandID), Arguments: []string{"key", "1d", "a", "5"}, Results: []int64{5, 1}},
+			changes: []Change{
+				{LSN: 2, Command: "CAPINCR", Key: "key", Capping: "1d", Timestamp: 10, Value: 5},
+			},
+		},
+		"denied capincr": {
+			log: &LogData{LSN: 3, CommandId: uint32(compute.CapIncrCommandID), Arguments: []string{"key", "60", "a", "5"}, Results: []int64{5, 0}},
+		},
+		"mcapincr": {
+			log: &LogData{
+				LSN:       4,
+				CommandId: uint32(compute.MCapIncrCommandID),
+				Arguments: []string{"a", "key", "60", "5", "key", "3600", "10"},
+				Results:   []int64{2, 7, 1},
+			},
+			changes: []Change{
+				{LSN: 4, Command: "MCAPINCR", Key: "key", Capping: "60", Timestamp: 10, Value: 2},
+				{LSN: 4, Command: "MCAPINCR", Key: "key", Capping: "3600", Timestamp: 10, Value: 7},
+			},
+		},
+		"mdel": {
+			log: &LogData{
+				LSN:       5,
+				CommandId: uint32(compute.MDelCommandID),
+				Arguments: []string{"a", "key1", "60", "key2", "60"},
+				Results:   []int64{0, 1},
+			},
+			changes: []Change{
+				{LSN: 5, Command: "MDEL", Key: "key2", Capping: "60", Timestamp: 10, Value: 0},
+			},
+		},
+		"mincr": {
+			log: &LogData{
+				LSN:       9,
+				CommandId: uint32(compute.MIncrCommandID),
+				Arguments: []string{"a", "key1", "60", "key2", "1d"},
+				Results:   []int64{3, 1},
+			},
+			changes: []Change{
+				{LSN: 9, Command: "MINCR", Key: "key1", Capping: "60", Timestamp: 10, Value: 3},
+				{LSN: 9, Command: "MINCR", Key: "key2", Capping: "1d", Timestamp: 10, Value: 1},
+			},
+		},
+		"log without results": {
+			log: &LogData{LSN: 6, CommandId: uint32(compute.IncrCommandID), Arguments: []string{"key", "60", "a"}},
+		},
+		"throttle": {
+			log: &LogData{LSN: 7, CommandId: uint32(compute.ThrottleCommandID), Arguments: []string{"key", "10", "60", "5", "1", "a"}, Results: []int64{1, 20}},
+		},
+		"invalid results": {
+			log: &LogData{LSN: 8, CommandId: uint32(compute.CapIncrCommandID), Arguments: []string{"key", "60", "a", "5"}, Results: []int64{5}},
+			err: ErrInvalidWALLog,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			changes, err := DecodeChanges(test.log)
+			require.ErrorIs(t, err, test.err)
+			require.Equal(t, test.changes, changes)
+		})
+	}
+}
+
+func TestFSReader_ReadLogsFrom(t *testing.T) {
+	directory := t.TempDir()
+	logger := zerolog.Nop()
+	fsWriter := NewFSWriter(directory, 10, &logger)
+	fsReader := NewFSReader(directory, &logger)
+
+	for i := 1; i <= 3; i++ {
+		now = func() time.Time {
+			return time.Unix(int64(i), 0)
+		}
+
+		lsn := uint64(i * 2)
+		fsWriter.WriteBatch([]Log{
+			NewLog(lsn-1, compute.IncrCommandID, []string{"key", "60", "a"}, []int64{1}),
+			NewLog(lsn, compute.IncrCommandID, []string{"key", "60", "a"}, []int64{2}),
+		})
+	}
+
+	ctx := context.Background()
+	logs, oldestLSN, err := fsReader.ReadLogsFrom(ctx, 2, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), oldestLSN)
+	require.Len(t, logs, 3)
+	require.Equal(t, uint64(2), logs[0].LSN)
+	require.Equal(t, uint64(4), logs[2].LSN)
+
+	logs, _, err = fsReader.ReadLogsFrom(ctx, 7, 10)
+	require.NoError(t, err)
+	require.Empty(t, logs)
+
+	// segments preceding fromLSN and following the limit are not decoded
+	appendGarbage(t, filepath.Join(directory, "wal_1000.log"))
+	appendGarbage(t, filepath.Join(directory, "wal_3000.log"))
+
+	logs, _, err = fsReader.ReadLogsFrom(ctx, 3, 2)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	require.Equal(t, uint64(3), logs[0].LSN)
+
+	_, _, err = fsReader.ReadLogsFrom(ctx, 3, 10)
+	require.Error(t, err)
+
+	// the first segment is removed after a dump
+	require.NoError(t, os.Remove(filepath.Join(directory, "wal_1000.log")))
+
+	_, oldestLSN, err = fsReader.ReadLogsFrom(ctx, 2, 10)
+	require.ErrorIs(t, err, ErrLSNTooOld)
+	require.Equal(t, uint64(3), oldestLSN)
+
+	logs, _, err = fsReader.ReadLogsFrom(ctx, 3, 2)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+}
+
+func TestWAL_RemovePastSegments(t *testing.T) {
+	directory := t.TempDir()
+	logger := zerolog.Nop()
+	fsWriter := NewFSWriter(directory, 10, &logger)
+	fsReader := NewFSReader(directory, &logger)
+	wal := NewWAL(fsWriter, fsReader, nil, time.Second, 10, directory, &logger)
+
+	for i := 1; i <= 3; i++ {
+		now = func() time.Time {
+			return time.Unix(int64(i), 0)
+		}
+
+		fsWriter.WriteBatch([]Log{NewLog(uint64(i), compute.IncrCommandID, []string{"key", "60", "a"}, []int64{1})})
+	}
+
+	ctx := context.Background()
+	require.NoError(t, wal.RemovePastSegments(ctx, 3))
+
+	segments, err := os.ReadDir(directory)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	require.Equal(t, "wal_3000.log", segments[0].Name())
+
+	// a dump covering every log keeps the newest segment, so old LSNs are still detected
+	require.NoError(t, wal.RemovePastSegments(ctx, 10))
+
+	segments, err = os.ReadDir(directory)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	_, oldestLSN, err := fsReader.ReadLogsFrom(ctx, 1, 10)
+	require.ErrorIs(t, err, ErrLSNTooOld)
+	require.Equal(t, uint64(3), oldestLSN)
+}
+
+func appendGarbage(t *testing.T, filename string) {
+	t.Helper()
+
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.Write([]byte{0, 0, 0, 3, 1, 2, 3})
+	require.NoError(t, err)
+}
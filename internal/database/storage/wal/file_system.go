@@ -3,6 +3,7 @@ package wal
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 )
 
@@ -51,6 +52,35 @@ func SegmentLast(directory string) (string, error) {
 	return filename, nil
 }
 
+// ArchiveSegments moves the segments of the directory to a new subdirectory, which is skipped
+// by readers, and returns its path. It returns an empty path for a directory without segments.
+func ArchiveSegments(directory string) (string, error) {
+	files, err := os.ReadDir(directory)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan WAL directory: %w", err)
+	}
+
+	archive := ""
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		if archive == "" {
+			archive = filepath.Join(directory, fmt.Sprintf("archive_%d", now().UnixMilli()))
+			if err := os.Mkdir(archive, 0o755); err != nil {
+				return "", fmt.Errorf("failed to create WAL archive: %w", err)
+			}
+		}
+
+		if err := os.Rename(filepath.Join(directory, file.Name()), filepath.Join(archive, file.Name())); err != nil {
+			return "", fmt.Errorf("failed to archive WAL segment: %w", err)
+		}
+	}
+
+	return archive, nil
+}
+
 func upperBound(array []string, target string) int {
 	low, high := 0, len(array)-1
 
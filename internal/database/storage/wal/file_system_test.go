@@ -1,5 +1,13 @@
 package wal
 
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
 //import (
 //	"testing"
 //
@@ -33,3 +41,28 @@ package wal
 //	require.NoError(t, err)
 //	require.Equal(t, "wal_3000.log", filename)
 //}
+
+func TestArchiveSegments(t *testing.T) {
+	directory := t.TempDir()
+
+	archive, err := ArchiveSegments(directory)
+	require.NoError(t, err)
+	require.Empty(t, archive)
+
+	for _, name := range []string{"wal_1000.log", "wal_2000.log"} {
+		require.NoError(t, os.WriteFile(filepath.Join(directory, name), []byte(name), 0o644))
+	}
+
+	archive, err = ArchiveSegments(directory)
+	require.NoError(t, err)
+	require.NotEmpty(t, archive)
+
+	segments, err := os.ReadDir(archive)
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+
+	files, err := os.ReadDir(directory)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.True(t, files[0].IsDir())
+}
@@ -0,0 +1,32 @@
+package wal
+
+import "sync"
+
+// FlushNotifier wakes up waiters when batches are written to a WAL segment,
+// e.g. replication requests of slaves waiting for new records.
+type FlushNotifier struct {
+	mutex   sync.Mutex
+	flushed chan struct{}
+}
+
+func NewFlushNotifier() *FlushNotifier {
+	return &FlushNotifier{
+		flushed: make(chan struct{}),
+	}
+}
+
+// Flushed returns a channel which is closed by the next flush.
+func (n *FlushNotifier) Flushed() <-chan struct{} {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.flushed
+}
+
+func (n *FlushNotifier) Notify() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	close(n.flushed)
+	n.flushed = make(chan struct{})
+}
@@ -3,7 +3,9 @@ package wal
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -60,6 +62,140 @@ func (r *FSReader) ReadLogs(ctx context.Context) ([]*LogData, error) {
 	return logs, nil
 }
 
+// ReadLogsFrom reads up to limit logs with LSN not less than fromLSN in the order of LSN
+// and returns the oldest retained LSN. It returns ErrLSNTooOld if segments with such logs were already removed.
+func (r *FSReader) ReadLogsFrom(ctx context.Context, fromLSN uint64, limit int) ([]*LogData, uint64, error) {
+	segments, err := r.segmentNames()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	firstLSNs := make([]uint64, len(segments))
+	for i, segment := range segments {
+		if firstLSNs[i], err = r.readFirstLSN(ctx, segment); err != nil {
+			return nil, 0, fmt.Errorf("failed to read WAL segment: %w", err)
+		}
+	}
+
+	oldestLSN := uint64(0)
+	for _, lsn := range firstLSNs {
+		if lsn != 0 {
+			oldestLSN = lsn
+
+			break
+		}
+	}
+
+	// the newest segment with logs is never removed, so older logs are known to be removed
+	if oldestLSN > fromLSN && fromLSN > 0 {
+		return nil, oldestLSN, fmt.Errorf("%w: %d (oldest retained LSN is %d)", ErrLSNTooOld, fromLSN, oldestLSN)
+	}
+
+	var logs []*LogData
+	for i, segment := range segments {
+		// segments are named by the creation time, so logs of a segment precede the first log of the next one
+		if i+1 < len(segments) && firstLSNs[i+1] != 0 && firstLSNs[i+1] <= fromLSN {
+			continue
+		}
+
+		data, err := os.ReadFile(segment)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read WAL segment: %w", err)
+		}
+
+		err = r.readBatches(ctx, data, func(batch []*LogData) bool {
+			for _, log := range batch {
+				if log.LSN >= fromLSN {
+					logs = append(logs, log)
+				}
+			}
+
+			return len(logs) < limit
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read WAL segment: %w", err)
+		}
+
+		if len(logs) >= limit {
+			break
+		}
+	}
+
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].LSN < logs[j].LSN
+	})
+
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+
+	return logs, oldestLSN, nil
+}
+
+// segmentNames returns paths of WAL segments in the order of creation.
+func (r *FSReader) segmentNames() ([]string, error) {
+	files, err := os.ReadDir(r.directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan WAL directory: %w", err)
+	}
+
+	segments := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() {
+			segments = append(segments, filepath.Join(r.directory, file.Name()))
+		}
+	}
+
+	return segments, nil
+}
+
+// readFirstLSN returns the least LSN of the first batch of the segment, 0 if the segment has no complete batches.
+func (r *FSReader) readFirstLSN(ctx context.Context, filename string) (uint64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	sizeBatchBytes := make([]byte, 4)
+	if _, err := io.ReadFull(file, sizeBatchBytes); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("failed to read first batch size from WAL segment: %w", err)
+	}
+
+	batchSize := bytesToUint32(sizeBatchBytes)
+	if batchSize > batchMaxSize {
+		return 0, fmt.Errorf("max batch size in WAL segment exceeded: %d (max: %d)", batchSize, batchMaxSize)
+	}
+
+	data := make([]byte, 4+batchSize)
+	copy(data, sizeBatchBytes)
+	if _, err := io.ReadFull(file, data[4:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// the first batch is being written
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("failed to read first batch data from WAL segment: %w", err)
+	}
+
+	firstLSN := uint64(0)
+	err = r.readBatches(ctx, data, func(batch []*LogData) bool {
+		for _, log := range batch {
+			if firstLSN == 0 || log.LSN < firstLSN {
+				firstLSN = log.LSN
+			}
+		}
+
+		return false
+	})
+
+	return firstLSN, err
+}
+
 func (r *FSReader) ReadSegment(ctx context.Context, filename string) ([]*LogData, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -71,39 +207,71 @@ func (r *FSReader) ReadSegment(ctx context.Context, filename string) ([]*LogData
 
 func (r *FSReader) ReadSegmentData(ctx context.Context, data []byte) ([]*LogData, error) {
 	var logs []*LogData
+	err := r.readBatches(ctx, data, func(batch []*LogData) bool {
+		logs = append(logs, batch...)
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// readBatches decodes batches of segment data in order and passes them to fn until it returns false.
+func (r *FSReader) readBatches(ctx context.Context, data []byte, fn func([]*LogData) bool) error {
 	buffer := bytes.NewBuffer(data)
 	sizeBatchBytes := make([]byte, 4)
 
 	for buffer.Len() > 0 {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 
 		_, err := buffer.Read(sizeBatchBytes)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read next batch size from WAL segment: %w", err)
+			return fmt.Errorf("failed to read next batch size from WAL segment: %w", err)
 		}
 
 		batchSize := bytesToUint32(sizeBatchBytes)
 		if batchSize > batchMaxSize {
-			return nil, fmt.Errorf("max batch size in WAL segment exceeded: %d (max: %d)", batchSize, batchMaxSize)
+			return fmt.Errorf("max batch size in WAL segment exceeded: %d (max: %d)", batchSize, batchMaxSize)
 		}
 
 		batchData := make([]byte, batchSize)
 		_, err = buffer.Read(batchData)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read next batch data from WAL segment: %w", err)
+			return fmt.Errorf("failed to read next batch data from WAL segment: %w", err)
 		}
 
 		var batch LogDataArray
 		if err := proto.Unmarshal(batchData, &batch); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal WAL segment: %w", err)
+			return fmt.Errorf("failed to unmarshal WAL segment: %w", err)
 		}
 
-		logs = append(logs, batch.Elems...)
+		if !fn(batch.Elems) {
+			return nil
+		}
 	}
 
-	return logs, nil
+	return nil
+}
+
+// CompleteBatchesSize returns the size of the prefix of segment data made of complete batches,
+// a batch being written to the segment at the moment is excluded.
+func CompleteBatchesSize(data []byte) int {
+	size := 0
+	for len(data)-size >= 4 {
+		batchSize := int(bytesToUint32(data[size : size+4]))
+		if len(data)-size-4 < batchSize {
+			break
+		}
+
+		size += 4 + batchSize
+	}
+
+	return size
 }
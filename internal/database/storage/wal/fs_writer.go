@@ -19,6 +19,8 @@ type FSWriter struct {
 	segmentSize    int
 	maxSegmentSize int
 
+	notifier *FlushNotifier
+
 	logger *zerolog.Logger
 }
 
@@ -30,6 +32,11 @@ func NewFSWriter(directory string, maxSegmentSize int, logger *zerolog.Logger) *
 	}
 }
 
+// SetFlushNotifier sets the notifier of written batches.
+func (w *FSWriter) SetFlushNotifier(notifier *FlushNotifier) {
+	w.notifier = notifier
+}
+
 func (w *FSWriter) WriteBatch(batch []Log) {
 	if len(batch) == 0 {
 		return
@@ -69,6 +76,10 @@ func (w *FSWriter) WriteBatch(batch []Log) {
 	}
 
 	w.acknowledgeWrite(batch, err)
+
+	if w.notifier != nil {
+		w.notifier.Notify()
+	}
 }
 
 func (w *FSWriter) writeLogs(logs []*LogData) error {
@@ -125,6 +136,19 @@ func (w *FSWriter) rotateSegment() error {
 	return nil
 }
 
+// Close closes the current segment, the next batch is written to a new one.
+func (w *FSWriter) Close() error {
+	if w.segment == nil {
+		return nil
+	}
+
+	err := w.segment.Close()
+	w.segment = nil
+	w.segmentSize = 0
+
+	return err
+}
+
 func uint32ToBytes(num uint32) []byte {
 	res := make([]byte, 4)
 	binary.BigEndian.PutUint32(res, num)
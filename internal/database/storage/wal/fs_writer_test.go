@@ -33,9 +33,9 @@ func TestBatchWritingToWALSegment(t *testing.T) {
 	fsWriter := NewFSWriter(testWALDirectory, maxSegmentSize, &logger)
 
 	batch := []Log{
-		NewLog(1, compute.IncrCommandID, []string{"key_1", "60"}),
-		NewLog(2, compute.IncrCommandID, []string{"key_2", "60"}),
-		NewLog(3, compute.IncrCommandID, []string{"key_3", "60"}),
+		NewLog(1, compute.IncrCommandID, []string{"key_1", "60"}, []int64{1}),
+		NewLog(2, compute.IncrCommandID, []string{"key_2", "60"}, []int64{1}),
+		NewLog(3, compute.IncrCommandID, []string{"key_3", "60"}, []int64{1}),
 	}
 
 	now = func() time.Time {
@@ -59,9 +59,9 @@ func TestWALSegmentsRotation(t *testing.T) {
 	fsWriter := NewFSWriter(testWALDirectory, maxSegmentSize, &logger)
 
 	batch := []Log{
-		NewLog(4, compute.IncrCommandID, []string{"key_4", "60"}),
-		NewLog(5, compute.IncrCommandID, []string{"key_5", "60"}),
-		NewLog(6, compute.IncrCommandID, []string{"key_6", "60"}),
+		NewLog(4, compute.IncrCommandID, []string{"key_4", "60"}, []int64{1}),
+		NewLog(5, compute.IncrCommandID, []string{"key_5", "60"}, []int64{1}),
+		NewLog(6, compute.IncrCommandID, []string{"key_6", "60"}, []int64{1}),
 	}
 
 	now = func() time.Time {
@@ -75,9 +75,9 @@ func TestWALSegmentsRotation(t *testing.T) {
 	}
 
 	batch = []Log{
-		NewLog(7, compute.IncrCommandID, []string{"key_7", "60"}),
-		NewLog(8, compute.IncrCommandID, []string{"key_8", "60"}),
-		NewLog(9, compute.IncrCommandID, []string{"key_9", "60"}),
+		NewLog(7, compute.IncrCommandID, []string{"key_7", "60"}, []int64{1}),
+		NewLog(8, compute.IncrCommandID, []string{"key_8", "60"}, []int64{1}),
+		NewLog(9, compute.IncrCommandID, []string{"key_9", "60"}, []int64{1}),
 	}
 
 	now = func() time.Time {
@@ -98,3 +98,59 @@ func TestWALSegmentsRotation(t *testing.T) {
 	require.NoError(t, err)
 	require.NotZero(t, stat.Size())
 }
+
+func TestCompleteBatchesSize(t *testing.T) {
+	maxSegmentSize := 100 << 10
+	logger := zerolog.Nop()
+	fsWriter := NewFSWriter(testWALDirectory, maxSegmentSize, &logger)
+
+	now = func() time.Time {
+		return time.Unix(4, 0)
+	}
+
+	for lsn := uint64(10); lsn < 12; lsn++ {
+		batch := []Log{NewLog(lsn, compute.IncrCommandID, []string{"key", "60"}, []int64{1})}
+		fsWriter.WriteBatch(batch)
+		result := batch[0].Result()
+		require.NoError(t, result.Get())
+	}
+
+	data, err := os.ReadFile(testWALDirectory + "/wal_4000.log")
+	require.NoError(t, err)
+	require.Equal(t, len(data), CompleteBatchesSize(data))
+	require.Zero(t, CompleteBatchesSize(data[:3]))
+
+	firstBatchSize := 4 + int(bytesToUint32(data[:4]))
+	require.Equal(t, firstBatchSize, CompleteBatchesSize(data[:len(data)-1]))
+}
+
+func TestWriteBatchNotifiesFlush(t *testing.T) {
+	maxSegmentSize := 100 << 10
+	logger := zerolog.Nop()
+	fsWriter := NewFSWriter(testWALDirectory, maxSegmentSize, &logger)
+
+	notifier := NewFlushNotifier()
+	fsWriter.SetFlushNotifier(notifier)
+
+	now = func() time.Time {
+		return time.Unix(5, 0)
+	}
+
+	flushed := notifier.Flushed()
+	select {
+	case <-flushed:
+		t.Fatal("flush is notified before a batch is written")
+	default:
+	}
+
+	batch := []Log{NewLog(12, compute.IncrCommandID, []string{"key", "60"}, []int64{1})}
+	fsWriter.WriteBatch(batch)
+
+	select {
+	case <-flushed:
+	default:
+		t.Fatal("flush is not notified")
+	}
+
+	require.NotEqual(t, flushed, notifier.Flushed())
+}
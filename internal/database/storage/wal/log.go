@@ -10,11 +10,12 @@ type Log struct {
 	writePromise tools.Promise[error]
 }
 
-func NewLog(lsn uint64, commandID compute.CommandID, args []string) Log {
+func NewLog(lsn uint64, commandID compute.CommandID, args []string, results []int64) Log {
 	logData := logDataPool.Get()
 	logData.LSN = lsn
 	logData.CommandId = uint32(commandID)
 	logData.Arguments = args
+	logData.Results = results
 
 	return Log{
 		data:         logData,
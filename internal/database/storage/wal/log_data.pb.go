@@ -28,6 +28,8 @@ type LogData struct {
 	LSN       uint64   `protobuf:"varint,1,opt,name=LSN,proto3" json:"LSN,omitempty"`
 	CommandId uint32   `protobuf:"varint,2,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
 	Arguments []string `protobuf:"bytes,3,rep,name=arguments,proto3" json:"arguments,omitempty"`
+	// results of the command applied by the engine, e.g. resulting counter values
+	Results []int64 `protobuf:"varint,4,rep,packed,name=results,proto3" json:"results,omitempty"`
 }
 
 func (x *LogData) Reset() {
@@ -83,6 +85,13 @@ func (x *LogData) GetArguments() []string {
 	return nil
 }
 
+func (x *LogData) GetResults() []int64 {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
 type LogDataArray struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -134,17 +143,18 @@ var File_log_data_proto protoreflect.FileDescriptor
 
 var file_log_data_proto_rawDesc = []byte{
 	0x0a, 0x0e, 0x6c, 0x6f, 0x67, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x12, 0x03, 0x77, 0x61, 0x6c, 0x22, 0x58, 0x0a, 0x07, 0x4c, 0x6f, 0x67, 0x44, 0x61, 0x74, 0x61,
+	0x12, 0x03, 0x77, 0x61, 0x6c, 0x22, 0x72, 0x0a, 0x07, 0x4c, 0x6f, 0x67, 0x44, 0x61, 0x74, 0x61,
 	0x12, 0x10, 0x0a, 0x03, 0x4c, 0x53, 0x4e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x4c,
 	0x53, 0x4e, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x5f, 0x69, 0x64,
 	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x49,
 	0x64, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x72, 0x67, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x03,
-	0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x61, 0x72, 0x67, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x22,
-	0x32, 0x0a, 0x0c, 0x4c, 0x6f, 0x67, 0x44, 0x61, 0x74, 0x61, 0x41, 0x72, 0x72, 0x61, 0x79, 0x12,
-	0x22, 0x0a, 0x05, 0x65, 0x6c, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c,
-	0x2e, 0x77, 0x61, 0x6c, 0x2e, 0x4c, 0x6f, 0x67, 0x44, 0x61, 0x74, 0x61, 0x52, 0x05, 0x65, 0x6c,
-	0x65, 0x6d, 0x73, 0x42, 0x06, 0x5a, 0x04, 0x2f, 0x77, 0x61, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x61, 0x72, 0x67, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12,
+	0x18, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x03,
+	0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0x32, 0x0a, 0x0c, 0x4c, 0x6f, 0x67,
+	0x44, 0x61, 0x74, 0x61, 0x41, 0x72, 0x72, 0x61, 0x79, 0x12, 0x22, 0x0a, 0x05, 0x65, 0x6c, 0x65,
+	0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x77, 0x61, 0x6c, 0x2e, 0x4c,
+	0x6f, 0x67, 0x44, 0x61, 0x74, 0x61, 0x52, 0x05, 0x65, 0x6c, 0x65, 0x6d, 0x73, 0x42, 0x06, 0x5a,
+	0x04, 0x2f, 0x77, 0x61, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -15,6 +15,7 @@ import (
 
 type fsWriter interface {
 	WriteBatch([]Log)
+	Close() error
 }
 
 type fsReader interface {
@@ -64,15 +65,24 @@ func NewWAL(
 	}
 }
 
+// Start starts the writer, it can be started again after Shutdown, e.g. when a slave is promoted.
 func (w *WAL) Start() {
+	closeCh := make(chan struct{})
+	closeDoneCh := make(chan struct{})
+	w.closeCh, w.closeDoneCh = closeCh, closeDoneCh
+
 	go func() {
-		defer close(w.closeDoneCh)
+		defer close(closeDoneCh)
 
 		for {
 			select {
-			case <-w.closeCh:
+			case <-closeCh:
 				w.flushBatch()
 
+				if err := w.fsWriter.Close(); err != nil {
+					w.logger.Warn().Err(err).Msg("failed to close WAL segment")
+				}
+
 				return
 			case batch := <-w.batches:
 				w.fsWriter.WriteBatch(batch)
@@ -101,19 +111,155 @@ func (w *WAL) Shutdown() {
 	}
 }
 
-func (w *WAL) Incr(ctx context.Context, txCtx database.TxContext, key database.BatchKey) tools.FutureError {
+// Incr logs an increment of the key, value is the resulting counter value.
+func (w *WAL) Incr(
+	ctx context.Context,
+	txCtx database.TxContext,
+	key database.BatchKey,
+	value database.ValueType,
+) tools.FutureError {
+	currTimeStr := strconv.FormatUint(uint64(txCtx.CurrTime), 16)
+
+	args := appendWindowMode([]string{key.Key, key.BatchSizeStr, currTimeStr}, key.Mode)
+
+	return w.push(ctx, txCtx.Tx, compute.IncrCommandID, args, []int64{int64(value)})
+}
+
+func (w *WAL) IncrBy(
+	ctx context.Context,
+	txCtx database.TxContext,
+	key database.BatchKey,
+	delta database.ValueType,
+	value database.ValueType,
+) tools.FutureError {
+	currTimeStr := strconv.FormatUint(uint64(txCtx.CurrTime), 16)
+	deltaStr := strconv.FormatInt(int64(delta), 10)
+
+	args := appendWindowMode([]string{key.Key, key.BatchSizeStr, currTimeStr, deltaStr}, key.Mode)
+
+	return w.push(ctx, txCtx.Tx, compute.IncrByCommandID, args, []int64{int64(value)})
+}
+
+func (w *WAL) CapIncr(
+	ctx context.Context,
+	txCtx database.TxContext,
+	key database.BatchKey,
+	limit database.ValueType,
+	value database.ValueType,
+	allowed bool,
+) tools.FutureError {
+	currTimeStr := strconv.FormatUint(uint64(txCtx.CurrTime), 16)
+	limitStr := strconv.FormatInt(int64(limit), 10)
+
+	args := appendWindowMode([]string{key.Key, key.BatchSizeStr, currTimeStr, limitStr}, key.Mode)
+
+	return w.push(ctx, txCtx.Tx, compute.CapIncrCommandID, args, []int64{int64(value), boolResult(allowed)})
+}
+
+func (w *WAL) MCapIncr(
+	ctx context.Context,
+	txCtx database.TxContext,
+	keys []database.BatchKey,
+	limits []database.ValueType,
+	values []database.ValueType,
+	allowed bool,
+) tools.FutureError {
+	currTimeStr := strconv.FormatUint(uint64(txCtx.CurrTime), 16)
+	arr := make([]string, 0, len(keys)*3+2)
+	arr = append(arr, currTimeStr)
+	for i, key := range keys {
+		arr = append(arr, key.Key, key.BatchSizeStr, strconv.FormatInt(int64(limits[i]), 10))
+	}
+
+	// window mode is the same for all keys of the query
+	if len(keys) > 0 {
+		arr = appendWindowMode(arr, keys[0].Mode)
+	}
+
+	results := make([]int64, 0, len(values)+1)
+	for _, value := range values {
+		results = append(results, int64(value))
+	}
+
+	return w.push(ctx, txCtx.Tx, compute.MCapIncrCommandID, arr, append(results, boolResult(allowed)))
+}
+
+// Throttle logs a request of the key at the moment now, allowed is the decision
+// and tat is the theoretical arrival time of the key after it.
+func (w *WAL) Throttle(
+	ctx context.Context,
+	txCtx database.TxContext,
+	key database.ThrottleKey,
+	now int64,
+	allowed bool,
+	tat int64,
+) tools.FutureError {
+	args := []string{
+		key.Key,
+		strconv.FormatInt(key.Rate, 10),
+		key.PeriodStr,
+		strconv.FormatInt(key.Burst, 10),
+		strconv.FormatInt(key.Quantity, 10),
+		strconv.FormatInt(now, 16),
+	}
+
+	return w.push(ctx, txCtx.Tx, compute.ThrottleCommandID, args, []int64{boolResult(allowed), tat})
+}
+
+func (w *WAL) SetPolicy(ctx context.Context, txCtx database.TxContext, policy database.Policy) tools.FutureError {
+	args := appendWindowMode([]string{
+		compute.PolicySetSubcommand,
+		policy.Name,
+		policy.Window.String(),
+		strconv.FormatInt(int64(policy.Limit), 10),
+	}, policy.Mode)
+
+	return w.push(ctx, txCtx.Tx, compute.PolicyCommandID, args, nil)
+}
+
+func (w *WAL) DelPolicy(ctx context.Context, txCtx database.TxContext, name string) tools.FutureError {
+	return w.push(ctx, txCtx.Tx, compute.PolicyCommandID, []string{compute.PolicyDelSubcommand, name}, nil)
+}
+
+func (w *WAL) Del(
+	ctx context.Context,
+	txCtx database.TxContext,
+	key database.BatchKey,
+	deleted bool,
+) tools.FutureError {
 	currTimeStr := strconv.FormatUint(uint64(txCtx.CurrTime), 16)
+	args := []string{key.Key, key.BatchSizeStr, currTimeStr}
 
-	return w.push(ctx, txCtx.Tx, compute.IncrCommandID, []string{key.Key, key.BatchSizeStr, currTimeStr})
+	return w.push(ctx, txCtx.Tx, compute.DelCommandID, args, []int64{boolResult(deleted)})
 }
 
-func (w *WAL) Del(ctx context.Context, txCtx database.TxContext, key database.BatchKey) tools.FutureError {
+func (w *WAL) MDel(
+	ctx context.Context,
+	txCtx database.TxContext,
+	keys []database.BatchKey,
+	deleted []bool,
+) tools.FutureError {
 	currTimeStr := strconv.FormatUint(uint64(txCtx.CurrTime), 16)
+	arr := make([]string, 0, len(keys)*2+1)
+	arr = append(arr, currTimeStr)
+	for _, key := range keys {
+		arr = append(arr, key.Key, key.BatchSizeStr)
+	}
+
+	results := make([]int64, 0, len(deleted))
+	for _, v := range deleted {
+		results = append(results, boolResult(v))
+	}
 
-	return w.push(ctx, txCtx.Tx, compute.DelCommandID, []string{key.Key, key.BatchSizeStr, currTimeStr})
+	return w.push(ctx, txCtx.Tx, compute.MDelCommandID, arr, results)
 }
 
-func (w *WAL) MDel(ctx context.Context, txCtx database.TxContext, keys []database.BatchKey) tools.FutureError {
+func (w *WAL) MIncr(
+	ctx context.Context,
+	txCtx database.TxContext,
+	keys []database.BatchKey,
+	values []database.ValueType,
+) tools.FutureError {
 	currTimeStr := strconv.FormatUint(uint64(txCtx.CurrTime), 16)
 	arr := make([]string, 0, len(keys)*2+1)
 	arr = append(arr, currTimeStr)
@@ -121,7 +267,42 @@ func (w *WAL) MDel(ctx context.Context, txCtx database.TxContext, keys []databas
 		arr = append(arr, key.Key, key.BatchSizeStr)
 	}
 
-	return w.push(ctx, txCtx.Tx, compute.MDelCommandID, arr)
+	results := make([]int64, 0, len(values))
+	for _, v := range values {
+		results = append(results, int64(v))
+	}
+
+	return w.push(ctx, txCtx.Tx, compute.MIncrCommandID, arr, results)
+}
+
+// appendWindowMode adds an explicitly requested window mode as the last argument of a log.
+func appendWindowMode(args []string, mode database.WindowMode) []string {
+	if mode == database.WindowModeDefault {
+		return args
+	}
+
+	return append(args, mode.String())
+}
+
+// boolResult encodes a boolean result of a command.
+func boolResult(v bool) int64 {
+	if v {
+		return 1
+	}
+
+	return 0
+}
+
+// Append logs a record of a command which isn't logged by the methods above, e.g. a registered command.
+// The record is replayed by the compute.Command.Replay of the command.
+func (w *WAL) Append(
+	ctx context.Context,
+	txCtx database.TxContext,
+	commandID compute.CommandID,
+	args []string,
+	results []int64,
+) tools.FutureError {
+	return w.push(ctx, txCtx.Tx, commandID, args, results)
 }
 
 func (w *WAL) flushBatch() {
@@ -143,8 +324,9 @@ func (w *WAL) push(
 	tx database.Tx,
 	commandID compute.CommandID,
 	args []string,
+	results []int64,
 ) tools.FutureError {
-	record := NewLog(uint64(tx), commandID, args)
+	record := NewLog(uint64(tx), commandID, args, results)
 
 	tools.WithLock(&w.mutex, func() {
 		w.batch = append(w.batch, record)
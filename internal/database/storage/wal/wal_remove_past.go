@@ -8,12 +8,16 @@ import (
 	"sort"
 )
 
+// RemovePastSegments removes segments with logs preceding lsn. The newest segment with logs is kept,
+// so readers know the oldest retained LSN and the WAL writer doesn't lose the segment it writes to.
 func (w *WAL) RemovePastSegments(ctx context.Context, lsn uint64) error {
 	files, err := os.ReadDir(w.directory)
 	if err != nil {
 		return fmt.Errorf("failed to scan WAL directory: %w", err)
 	}
 
+	var past []string
+	newest := ""
 	for _, file := range files {
 		if file.IsDir() {
 			continue
@@ -29,16 +33,26 @@ func (w *WAL) RemovePastSegments(ctx context.Context, lsn uint64) error {
 			continue
 		}
 
+		newest = filePath
+
 		sort.Slice(logs, func(i, j int) bool {
 			return logs[i].LSN < logs[j].LSN
 		})
 
 		if logs[len(logs)-1].LSN < lsn {
-			w.logger.Debug().Msg(fmt.Sprintf("removing segment %s", filePath))
+			past = append(past, filePath)
+		}
+	}
+
+	for _, filePath := range past {
+		if filePath == newest {
+			continue
+		}
+
+		w.logger.Debug().Msg(fmt.Sprintf("removing segment %s", filePath))
 
-			if err := os.Remove(filePath); err != nil {
-				return fmt.Errorf("failed to remove segment %s: %w", filePath, err)
-			}
+		if err := os.Remove(filePath); err != nil {
+			return fmt.Errorf("failed to remove segment %s: %w", filePath, err)
 		}
 	}
 
@@ -1,12 +1,18 @@
 package database
 
+import (
+	"math"
+	"strings"
+	"time"
+)
+
 const (
-	ErrorValue ValueType = -1
+	MaxValue ValueType = math.MaxInt64
 
 	NoTx Tx = 0
 )
 
-type ValueType int32
+type ValueType int64
 
 type Tx uint64
 
@@ -17,18 +23,162 @@ type TxContext struct {
 	DumpTx   Tx
 	CurrTime TxTime
 	FromWAL  bool
+	// DryRun makes a write return its result without changing the state
+	DryRun bool
+}
+
+// WindowMode defines how a counter value is calculated for a capping window.
+type WindowMode uint8
+
+const (
+	// WindowModeDefault means the mode the key was last written with (fixed for new keys)
+	WindowModeDefault WindowMode = iota
+	// WindowModeFixed counts events in the current epoch-aligned window only
+	WindowModeFixed
+	// WindowModeSliding weights the previous window by the part of it still covered by the sliding window
+	WindowModeSliding
+)
+
+const (
+	WindowModeFixedName   = "FIXED"
+	WindowModeSlidingName = "SLIDING"
+)
+
+var ErrInvalidWindowMode = NewError(ErrorCodeArgs, "invalid window mode")
+
+func ParseWindowMode(str string) (WindowMode, error) {
+	switch strings.ToUpper(str) {
+	case WindowModeFixedName:
+		return WindowModeFixed, nil
+	case WindowModeSlidingName:
+		return WindowModeSliding, nil
+	default:
+		return WindowModeDefault, ErrInvalidWindowMode
+	}
+}
+
+func (m WindowMode) String() string {
+	switch m {
+	case WindowModeFixed:
+		return WindowModeFixedName
+	case WindowModeSliding:
+		return WindowModeSlidingName
+	default:
+		return ""
+	}
 }
 
 type BatchKey struct {
-	BatchSize    uint32
+	Window       Window
 	BatchSizeStr string
 	Key          string
+	Mode         WindowMode
 }
 
+// DumpElemKind is a kind of engine state stored in a dump element.
+type DumpElemKind uint8
+
+const (
+	DumpElemCounter DumpElemKind = iota
+	DumpElemThrottle
+	DumpElemPolicy
+)
+
 type DumpElem struct {
+	Kind      DumpElemKind
 	Key       string
-	BatchSize uint32
+	BatchSize uint32 // size of an epoch-aligned window, kept for dumps without Window
+	Window    string // window spec
 	Value     ValueType
+	PrevValue ValueType
+	Mode      WindowMode
 	TxAt      TxTime
 	Tx        Tx
+	TAT       int64 // theoretical arrival time of a throttle in unix nanoseconds
+	History   []HistoryEntry
+}
+
+// HistoryEntry is the value of a closed window starting at Start (unix time).
+type HistoryEntry struct {
+	Start int64
+	Value ValueType
+}
+
+var (
+	ErrPolicyNotFound = NewError(ErrorCodeNotFound, "policy not found")
+	ErrPolicyReadOnly = NewError(ErrorCodeReadOnly, "policy is defined in the config")
+)
+
+// Policy is a named capping applied by HIT: the counter of a key in Window is capped by Limit.
+type Policy struct {
+	Name   string
+	Window Window
+	Limit  ValueType
+	Mode   WindowMode
+	Static bool // defined in the config, can't be changed at runtime
+}
+
+var ErrHistoryTooLong = NewError(ErrorCodeArgs, "history length exceeds history size")
+
+var ErrInvalidKeyPattern = NewError(ErrorCodeArgs, "invalid key pattern")
+
+// ThresholdEvent is sent to threshold subscribers when a write makes a counter reach the limit.
+type ThresholdEvent struct {
+	Key    string
+	Window Window
+	Limit  ValueType
+	Value  ValueType
+}
+
+var (
+	ErrInvalidThrottle  = NewError(ErrorCodeArgs, "invalid throttle parameters")
+	ErrThrottleTooLarge = NewError(ErrorCodeArgs, "throttle parameters are too large")
+)
+
+// ThrottleKey describes a GCRA rate limit: Rate requests per Period
+// with up to Burst requests on top of the steady rate.
+type ThrottleKey struct {
+	Key       string
+	Rate      int64
+	Period    uint32
+	PeriodStr string
+	Burst     int64
+	Quantity  int64
+}
+
+// EmissionInterval is the time between two requests at the steady rate.
+func (k ThrottleKey) EmissionInterval() time.Duration {
+	return time.Duration(int64(k.Period) * int64(time.Second) / k.Rate)
+}
+
+// DelayVariationTolerance is how far ahead of now the theoretical arrival time may get.
+func (k ThrottleKey) DelayVariationTolerance() time.Duration {
+	return k.EmissionInterval() * time.Duration(k.Burst+1)
+}
+
+// Validate checks the parameters and that the throttle time calculations don't overflow.
+func (k ThrottleKey) Validate() error {
+	if k.Rate <= 0 || k.Period == 0 || k.Burst < 0 || k.Quantity < 0 {
+		return ErrInvalidThrottle
+	}
+
+	interval := k.EmissionInterval()
+	if interval <= 0 {
+		return ErrThrottleTooLarge
+	}
+
+	limit := math.MaxInt64 / 4 / int64(interval)
+	if k.Burst+1 > limit || k.Quantity > limit {
+		return ErrThrottleTooLarge
+	}
+
+	return nil
+}
+
+type ThrottleResult struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	RetryAfter time.Duration
+	ResetAfter time.Duration
 }
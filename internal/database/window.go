@@ -0,0 +1,231 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // nodes without system zoneinfo must compute the same window boundaries
+)
+
+// WindowUnit is a unit of a capping window size.
+type WindowUnit uint8
+
+const (
+	// WindowUnitSecond windows are aligned to the unix epoch
+	WindowUnitSecond WindowUnit = iota
+	// WindowUnitDay windows start at local midnight
+	WindowUnitDay
+	// WindowUnitWeek windows start at local midnight of Monday (ISO week)
+	WindowUnitWeek
+	// WindowUnitMonth windows start at local midnight of the first day of a month
+	WindowUnitMonth
+)
+
+const (
+	windowUnitSecondSuffix = "s"
+	windowUnitDaySuffix    = "d"
+	windowUnitWeekSuffix   = "w"
+	windowUnitMonthSuffix  = "mo"
+	windowZoneSeparator    = "@"
+
+	secondsPerDay = 24 * 60 * 60
+	// epochMondayDays is the number of days from the unix epoch to the first Monday (1970-01-05)
+	epochMondayDays = 4
+)
+
+var (
+	ErrInvalidWindow       = NewError(ErrorCodeArgs, "invalid window")
+	ErrInvalidWindowZone   = NewError(ErrorCodeArgs, "invalid window time zone")
+	ErrWindowZoneNotNeeded = NewError(ErrorCodeArgs, "time zone is supported for calendar windows only")
+)
+
+// locations caches loaded time zones, so windows with the same spec are equal.
+var locations sync.Map
+
+// Window is a capping window: a number of seconds aligned to the unix epoch
+// or a number of calendar days, weeks or months in a time zone.
+// Windows parsed from equal specs are equal, so Window can be used as a map key.
+type Window struct {
+	size uint32
+	unit WindowUnit
+	loc  *time.Location // nil means UTC
+}
+
+// NewSecondsWindow makes an epoch-aligned window of the given number of seconds.
+func NewSecondsWindow(seconds uint32) Window {
+	return Window{size: seconds, unit: WindowUnitSecond}
+}
+
+// ParseWindow parses a window spec: a number of seconds (e.g. "3600" or "3600s")
+// or a calendar window with an optional IANA time zone (e.g. "1d@Europe/Berlin", "1w", "1mo").
+func ParseWindow(spec string) (Window, error) {
+	sizeStr, zone, hasZone := strings.Cut(spec, windowZoneSeparator)
+
+	var window Window
+	switch {
+	case strings.HasSuffix(sizeStr, windowUnitMonthSuffix):
+		window.unit = WindowUnitMonth
+		sizeStr = strings.TrimSuffix(sizeStr, windowUnitMonthSuffix)
+	case strings.HasSuffix(sizeStr, windowUnitWeekSuffix):
+		window.unit = WindowUnitWeek
+		sizeStr = strings.TrimSuffix(sizeStr, windowUnitWeekSuffix)
+	case strings.HasSuffix(sizeStr, windowUnitDaySuffix):
+		window.unit = WindowUnitDay
+		sizeStr = strings.TrimSuffix(sizeStr, windowUnitDaySuffix)
+	case strings.HasSuffix(sizeStr, windowUnitSecondSuffix):
+		sizeStr = strings.TrimSuffix(sizeStr, windowUnitSecondSuffix)
+	}
+
+	size, err := strconv.ParseUint(sizeStr, 10, 32)
+	if err != nil || size == 0 {
+		return Window{}, fmt.Errorf("%w: %s", ErrInvalidWindow, spec)
+	}
+	window.size = uint32(size)
+
+	if !hasZone {
+		return window, nil
+	}
+
+	if window.unit == WindowUnitSecond {
+		return Window{}, fmt.Errorf("%w: %s", ErrWindowZoneNotNeeded, spec)
+	}
+
+	if window.loc, err = loadLocation(zone); err != nil {
+		return Window{}, fmt.Errorf("%w: %s", ErrInvalidWindowZone, zone)
+	}
+
+	return window, nil
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	// the local zone of a node is not a part of the spec, so replicas could disagree on it
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidWindowZone
+	}
+
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if loc == time.UTC {
+		return nil, nil
+	}
+
+	actual, _ := locations.LoadOrStore(name, loc)
+
+	return actual.(*time.Location), nil
+}
+
+// IsCalendar reports whether the window is a calendar one.
+func (w Window) IsCalendar() bool {
+	return w.unit != WindowUnitSecond
+}
+
+// Seconds returns the size of an epoch-aligned window and 0 for calendar windows.
+func (w Window) Seconds() uint32 {
+	if w.IsCalendar() {
+		return 0
+	}
+
+	return w.size
+}
+
+// String returns the canonical spec of the window.
+func (w Window) String() string {
+	str := strconv.FormatUint(uint64(w.size), 10)
+
+	switch w.unit {
+	case WindowUnitDay:
+		str += windowUnitDaySuffix
+	case WindowUnitWeek:
+		str += windowUnitWeekSuffix
+	case WindowUnitMonth:
+		str += windowUnitMonthSuffix
+	default:
+		return str
+	}
+
+	if w.loc != nil {
+		str += windowZoneSeparator + w.loc.String()
+	}
+
+	return str
+}
+
+// Start returns the unix time of the start of the window containing t.
+func (w Window) Start(t int64) int64 {
+	if w.unit == WindowUnitSecond {
+		size := int64(w.size)
+
+		return floorDiv(t, size) * size
+	}
+
+	return w.calendarStart(w.index(t))
+}
+
+// End returns the unix time of the start of the window following the one containing t.
+func (w Window) End(t int64) int64 {
+	if w.unit == WindowUnitSecond {
+		return w.Start(t) + int64(w.size)
+	}
+
+	return w.calendarStart(w.index(t) + 1)
+}
+
+// index returns the number of the calendar window containing t counting from the unix epoch.
+func (w Window) index(t int64) int64 {
+	y, m, d := time.Unix(t, 0).In(w.location()).Date()
+	days := floorDiv(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix(), secondsPerDay)
+
+	var units int64
+	switch w.unit {
+	case WindowUnitDay:
+		units = days
+	case WindowUnitWeek:
+		units = floorDiv(days-epochMondayDays, 7)
+	case WindowUnitMonth:
+		units = int64(y-1970)*12 + int64(m-1)
+	}
+
+	return floorDiv(units, int64(w.size))
+}
+
+func (w Window) calendarStart(index int64) int64 {
+	units := int(index * int64(w.size))
+
+	var start time.Time
+	switch w.unit {
+	case WindowUnitDay:
+		start = time.Date(1970, time.January, 1+units, 0, 0, 0, 0, w.location())
+	case WindowUnitWeek:
+		start = time.Date(1970, time.January, 1+epochMondayDays+units*7, 0, 0, 0, 0, w.location())
+	case WindowUnitMonth:
+		start = time.Date(1970, time.January+time.Month(units), 1, 0, 0, 0, 0, w.location())
+	}
+
+	return start.Unix()
+}
+
+func (w Window) location() *time.Location {
+	if w.loc == nil {
+		return time.UTC
+	}
+
+	return w.loc
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+
+	return q
+}
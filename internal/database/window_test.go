@@ -0,0 +1,118 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := map[string]struct {
+		spec      string
+		canonical string
+		err       error
+	}{
+		"seconds":             {spec: "3600", canonical: "3600"},
+		"seconds with suffix": {spec: "3600s", canonical: "3600"},
+		"day":                 {spec: "1d", canonical: "1d"},
+		"week":                {spec: "2w", canonical: "2w"},
+		"month":               {spec: "1mo", canonical: "1mo"},
+		"day with zone":       {spec: "1d@Europe/Berlin", canonical: "1d@Europe/Berlin"},
+		"day with utc zone":   {spec: "1d@UTC", canonical: "1d"},
+		"zero size":           {spec: "0d", err: database.ErrInvalidWindow},
+		"unknown unit":        {spec: "1y", err: database.ErrInvalidWindow},
+		"not a number":        {spec: "abc", err: database.ErrInvalidWindow},
+		"zone for seconds":    {spec: "60@Europe/Berlin", err: database.ErrWindowZoneNotNeeded},
+		"unknown zone":        {spec: "1d@Mars/Olympus", err: database.ErrInvalidWindowZone},
+		"local zone":          {spec: "1d@Local", err: database.ErrInvalidWindowZone},
+		"empty zone":          {spec: "1d@", err: database.ErrInvalidWindowZone},
+		"seconds out of uint": {spec: "4294967296", err: database.ErrInvalidWindow},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			window, err := database.ParseWindow(test.spec)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.canonical, window.String())
+
+			again, err := database.ParseWindow(window.String())
+			require.NoError(t, err)
+			require.Equal(t, window, again)
+		})
+	}
+}
+
+func TestWindow_Bounds(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		spec  string
+		at    time.Time
+		start time.Time
+		end   time.Time
+	}{
+		"seconds": {
+			spec:  "3600",
+			at:    time.Date(2025, 3, 30, 12, 30, 0, 0, time.UTC),
+			start: time.Date(2025, 3, 30, 12, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 3, 30, 13, 0, 0, 0, time.UTC),
+		},
+		"utc day": {
+			spec:  "1d",
+			at:    time.Date(2025, 3, 30, 23, 30, 0, 0, time.UTC),
+			start: time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		},
+		"local day": {
+			spec:  "1d@Europe/Berlin",
+			at:    time.Date(2025, 3, 30, 23, 30, 0, 0, time.UTC),
+			start: time.Date(2025, 3, 31, 0, 0, 0, 0, berlin),
+			end:   time.Date(2025, 4, 1, 0, 0, 0, 0, berlin),
+		},
+		"local day with DST switch is 23 hours": {
+			spec:  "1d@Europe/Berlin",
+			at:    time.Date(2025, 3, 30, 12, 0, 0, 0, berlin),
+			start: time.Date(2025, 3, 30, 0, 0, 0, 0, berlin),
+			end:   time.Date(2025, 3, 31, 0, 0, 0, 0, berlin),
+		},
+		"iso week starts on monday": {
+			spec:  "1w@Europe/Berlin",
+			at:    time.Date(2025, 10, 19, 23, 0, 0, 0, berlin),
+			start: time.Date(2025, 10, 13, 0, 0, 0, 0, berlin),
+			end:   time.Date(2025, 10, 20, 0, 0, 0, 0, berlin),
+		},
+		"month": {
+			spec:  "1mo",
+			at:    time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC),
+			start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"quarter": {
+			spec:  "3mo",
+			at:    time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC),
+			start: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			window, err := database.ParseWindow(test.spec)
+			require.NoError(t, err)
+			require.Equal(t, test.start.Unix(), window.Start(test.at.Unix()))
+			require.Equal(t, test.end.Unix(), window.End(test.at.Unix()))
+		})
+	}
+}
@@ -0,0 +1,334 @@
+// Package gateway is an HTTP/JSON API to the database.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"fq/internal/database"
+	"fq/internal/database/compute"
+)
+
+const (
+	defaultWatchTimeout = 30 * time.Second
+	shutdownTimeout     = 5 * time.Second
+)
+
+type commandHandler interface {
+	HandleCommand(ctx context.Context, args []string) database.Reply
+}
+
+// HTTPServer routes HTTP requests to database commands and encodes replies as JSON.
+type HTTPServer struct {
+	address         string
+	maxBodySize     int64
+	maxWatchTimeout time.Duration
+	logger          *zerolog.Logger
+}
+
+func NewHTTPServer(
+	address string,
+	maxBodySize int,
+	maxWatchTimeout time.Duration,
+	logger *zerolog.Logger,
+) (*HTTPServer, error) {
+	if logger == nil {
+		return nil, errors.New("logger is invalid")
+	}
+
+	if maxBodySize <= 0 {
+		return nil, errors.New("invalid max body size")
+	}
+
+	return &HTTPServer{
+		address:         address,
+		maxBodySize:     int64(maxBodySize),
+		maxWatchTimeout: maxWatchTimeout,
+		logger:          logger,
+	}, nil
+}
+
+// HandleRequests serves requests until the context is canceled.
+func (s *HTTPServer) HandleRequests(ctx context.Context, handler commandHandler) error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	server := &http.Server{
+		Handler:           s.routes(handler),
+		ReadHeaderTimeout: 10 * time.Second,
+		// long polls are canceled when the server stops
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to shutdown http server")
+		}
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+
+	return nil
+}
+
+func (s *HTTPServer) routes(handler commandHandler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/incr", func(w http.ResponseWriter, r *http.Request) {
+		s.handleIncr(w, r, handler)
+	})
+	mux.HandleFunc("GET /v1/counters/{key}", func(w http.ResponseWriter, r *http.Request) {
+		s.handleGet(w, r, handler)
+	})
+	mux.HandleFunc("POST /v1/mdel", func(w http.ResponseWriter, r *http.Request) {
+		s.handleMDel(w, r, handler)
+	})
+	mux.HandleFunc("GET /v1/watch", func(w http.ResponseWriter, r *http.Request) {
+		s.handleWatch(w, r, handler)
+	})
+
+	return mux
+}
+
+type incrRequest struct {
+	Key     string `json:"key"`
+	Capping string `json:"capping"`
+	// Delta increments by a value, INCRBY is used for it
+	Delta *int64 `json:"delta,omitempty"`
+	Mode  string `json:"mode,omitempty"`
+	// At is the unix time of the event
+	At *int64 `json:"at,omitempty"`
+}
+
+type counterKey struct {
+	Key     string `json:"key"`
+	Capping string `json:"capping"`
+}
+
+type mdelRequest struct {
+	Keys []counterKey `json:"keys"`
+}
+
+type valueResponse struct {
+	Key     string `json:"key"`
+	Capping string `json:"capping"`
+	Value   int64  `json:"value"`
+}
+
+type mdelResponse struct {
+	Deleted []bool `json:"deleted"`
+}
+
+type watchResponse struct {
+	Key     string `json:"key"`
+	Capping string `json:"capping"`
+	Changed bool   `json:"changed"`
+	Value   int64  `json:"value,omitempty"`
+}
+
+type errorResponse struct {
+	Code  database.ErrorCode `json:"code,omitempty"`
+	Error string             `json:"error"`
+}
+
+func (s *HTTPServer) handleIncr(w http.ResponseWriter, r *http.Request, handler commandHandler) {
+	var request incrRequest
+	if !s.decodeBody(w, r, &request) {
+		return
+	}
+
+	args := []string{compute.IncrCommand, request.Key, request.Capping}
+	if request.Delta != nil {
+		args = []string{compute.IncrByCommand, request.Key, request.Capping, strconv.FormatInt(*request.Delta, 10)}
+	}
+
+	if request.Mode != "" {
+		args = append(args, request.Mode)
+	}
+
+	if request.At != nil {
+		args = append(args, "AT", strconv.FormatInt(*request.At, 10))
+	}
+
+	value, ok := s.handleValue(w, r, handler, args)
+	if !ok {
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, valueResponse{Key: request.Key, Capping: request.Capping, Value: value})
+}
+
+func (s *HTTPServer) handleGet(w http.ResponseWriter, r *http.Request, handler commandHandler) {
+	key := r.PathValue("key")
+	capping := r.URL.Query().Get("capping")
+
+	args := []string{compute.GetCommand, key, capping}
+	if mode := r.URL.Query().Get("mode"); mode != "" {
+		args = append(args, mode)
+	}
+
+	value, ok := s.handleValue(w, r, handler, args)
+	if !ok {
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, valueResponse{Key: key, Capping: capping, Value: value})
+}
+
+func (s *HTTPServer) handleMDel(w http.ResponseWriter, r *http.Request, handler commandHandler) {
+	var request mdelRequest
+	if !s.decodeBody(w, r, &request) {
+		return
+	}
+
+	args := make([]string, 0, len(request.Keys)*2+1)
+	args = append(args, compute.MDelCommand)
+	for _, key := range request.Keys {
+		args = append(args, key.Key, key.Capping)
+	}
+
+	reply := handler.HandleCommand(r.Context(), args)
+	if reply.Err != nil {
+		s.writeError(w, reply.Err)
+
+		return
+	}
+
+	deleted := make([]bool, 0, len(reply.Values))
+	for _, value := range reply.Values {
+		deleted = append(deleted, value.Int != 0)
+	}
+
+	s.writeJSON(w, http.StatusOK, mdelResponse{Deleted: deleted})
+}
+
+// handleWatch waits until the value of the key changes or the timeout (?timeout=30s) expires.
+func (s *HTTPServer) handleWatch(w http.ResponseWriter, r *http.Request, handler commandHandler) {
+	query := r.URL.Query()
+	key, capping := query.Get("key"), query.Get("capping")
+
+	timeout := defaultWatchTimeout
+	if timeoutStr := query.Get("timeout"); timeoutStr != "" {
+		var err error
+		if timeout, err = time.ParseDuration(timeoutStr); err != nil || timeout <= 0 {
+			s.writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid timeout"})
+
+			return
+		}
+	}
+
+	timeout = min(timeout, s.maxWatchTimeout)
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	reply := handler.HandleCommand(ctx, []string{compute.WatchCommand, key, capping})
+	if errors.Is(reply.Err, context.DeadlineExceeded) && r.Context().Err() == nil {
+		s.writeJSON(w, http.StatusOK, watchResponse{Key: key, Capping: capping})
+
+		return
+	}
+
+	value, ok := s.valueOf(w, reply)
+	if !ok {
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, watchResponse{Key: key, Capping: capping, Changed: true, Value: value})
+}
+
+func (s *HTTPServer) handleValue(
+	w http.ResponseWriter,
+	r *http.Request,
+	handler commandHandler,
+	args []string,
+) (int64, bool) {
+	return s.valueOf(w, handler.HandleCommand(r.Context(), args))
+}
+
+// valueOf returns the single value of the reply or writes the error of the reply.
+func (s *HTTPServer) valueOf(w http.ResponseWriter, reply database.Reply) (int64, bool) {
+	if reply.Err != nil {
+		s.writeError(w, reply.Err)
+
+		return 0, false
+	}
+
+	if len(reply.Values) != 1 {
+		s.logger.Error().Int("values", len(reply.Values)).Msg("unexpected reply of a single value command")
+		s.writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "unexpected reply"})
+
+		return 0, false
+	}
+
+	return reply.Values[0].Int, true
+}
+
+func (s *HTTPServer) decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		status := http.StatusBadRequest
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+
+		s.writeJSON(w, status, errorResponse{Error: "invalid request body: " + err.Error()})
+
+		return false
+	}
+
+	return true
+}
+
+func (s *HTTPServer) writeError(w http.ResponseWriter, err error) {
+	s.writeJSON(w, errorStatus(err), errorResponse{Code: database.ErrorCodeOf(err), Error: err.Error()})
+}
+
+// errorStatus maps codes of errors of queries to HTTP statuses.
+func errorStatus(err error) int {
+	switch database.ErrorCodeOf(err) {
+	case database.ErrorCodeArgs:
+		return http.StatusBadRequest
+	case database.ErrorCodeNotFound:
+		return http.StatusNotFound
+	case database.ErrorCodeReadOnly, database.ErrorCodeOverflow:
+		return http.StatusConflict
+	case database.ErrorCodeTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case database.ErrorCodeUnsupported:
+		return http.StatusNotImplemented
+	case database.ErrorCodeDurability, database.ErrorCodeUnavailable, database.ErrorCodeTimeout:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *HTTPServer) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Warn().Err(err).Msg("failed to write response")
+	}
+}
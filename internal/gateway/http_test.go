@@ -0,0 +1,145 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"fq/internal/database"
+	"fq/internal/database/compute"
+)
+
+type handlerFunc func(ctx context.Context, args []string) database.Reply
+
+func (f handlerFunc) HandleCommand(ctx context.Context, args []string) database.Reply {
+	return f(ctx, args)
+}
+
+func TestHTTPServer_Routes(t *testing.T) {
+	tests := map[string]struct {
+		method   string
+		target   string
+		body     string
+		args     []string
+		reply    database.Reply
+		status   int
+		response string
+	}{
+		"incr": {
+			method:   http.MethodPost,
+			target:   "/v1/incr",
+			body:     `{"key":"user","capping":"600"}`,
+			args:     []string{"INCR", "user", "600"},
+			reply:    database.Reply{Values: []database.ReplyValue{database.IntReplyValue(3)}},
+			status:   http.StatusOK,
+			response: `{"key":"user","capping":"600","value":3}`,
+		},
+		"incr by delta at time": {
+			method:   http.MethodPost,
+			target:   "/v1/incr",
+			body:     `{"key":"user","capping":"1d","delta":5,"mode":"SLIDING","at":1700000000}`,
+			args:     []string{"INCRBY", "user", "1d", "5", "SLIDING", "AT", "1700000000"},
+			reply:    database.Reply{Values: []database.ReplyValue{database.IntReplyValue(5)}},
+			status:   http.StatusOK,
+			response: `{"key":"user","capping":"1d","value":5}`,
+		},
+		"get": {
+			method:   http.MethodGet,
+			target:   "/v1/counters/user:1?capping=600",
+			args:     []string{"GET", "user:1", "600"},
+			reply:    database.Reply{Values: []database.ReplyValue{database.IntReplyValue(7)}},
+			status:   http.StatusOK,
+			response: `{"key":"user:1","capping":"600","value":7}`,
+		},
+		"get with invalid capping": {
+			method:   http.MethodGet,
+			target:   "/v1/counters/user?capping=abc",
+			args:     []string{"GET", "user", "abc"},
+			reply:    database.Reply{Err: database.ErrInvalidWindow},
+			status:   http.StatusBadRequest,
+			response: `{"code":"E_ARGS","error":"invalid window"}`,
+		},
+		"mdel": {
+			method: http.MethodPost,
+			target: "/v1/mdel",
+			body:   `{"keys":[{"key":"a","capping":"60"},{"key":"b","capping":"60"}]}`,
+			args:   []string{"MDEL", "a", "60", "b", "60"},
+			reply: database.Reply{
+				Values: []database.ReplyValue{database.BoolReplyValue(true), database.BoolReplyValue(false)},
+				Array:  true,
+			},
+			status:   http.StatusOK,
+			response: `{"deleted":[true,false]}`,
+		},
+		"invalid body": {
+			method:   http.MethodPost,
+			target:   "/v1/incr",
+			body:     `{"key":"user","unknown":1}`,
+			status:   http.StatusBadRequest,
+			response: `{"error":"invalid request body: json: unknown field \"unknown\""}`,
+		},
+		"storage error": {
+			method:   http.MethodPost,
+			target:   "/v1/incr",
+			body:     `{"key":"user","capping":"600"}`,
+			args:     []string{"INCR", "user", "600"},
+			reply:    database.Reply{Err: context.Canceled},
+			status:   http.StatusServiceUnavailable,
+			response: `{"code":"E_TIMEOUT","error":"context canceled"}`,
+		},
+		"watch timeout": {
+			method:   http.MethodGet,
+			target:   "/v1/watch?key=user&capping=600&timeout=10ms",
+			args:     []string{"WATCH", "user", "600"},
+			reply:    database.Reply{Err: context.DeadlineExceeded},
+			status:   http.StatusOK,
+			response: `{"key":"user","capping":"600","changed":false}`,
+		},
+		"watch": {
+			method:   http.MethodGet,
+			target:   "/v1/watch?key=user&capping=600",
+			args:     []string{"WATCH", "user", "600"},
+			reply:    database.Reply{Values: []database.ReplyValue{database.IntReplyValue(2)}},
+			status:   http.StatusOK,
+			response: `{"key":"user","capping":"600","changed":true,"value":2}`,
+		},
+	}
+
+	logger := zerolog.Nop()
+	server, err := NewHTTPServer(":0", 1024, time.Second, &logger)
+	require.NoError(t, err)
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var args []string
+			handler := server.routes(handlerFunc(func(_ context.Context, a []string) database.Reply {
+				args = a
+
+				return test.reply
+			}))
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+
+			require.Equal(t, test.args, args)
+			require.Equal(t, test.status, recorder.Code)
+			require.JSONEq(t, test.response, recorder.Body.String())
+		})
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	require.Equal(t, http.StatusBadRequest, errorStatus(compute.ErrInvalidArguments))
+	require.Equal(t, http.StatusNotFound, errorStatus(database.ErrPolicyNotFound))
+	require.Equal(t, http.StatusConflict, errorStatus(database.ErrValueOverflow))
+	require.Equal(t, http.StatusServiceUnavailable, errorStatus(fmt.Errorf("%w: disk is full", database.ErrNotDurable)))
+	require.Equal(t, http.StatusInternalServerError, errorStatus(database.ErrDumpReadSessionClosed))
+}
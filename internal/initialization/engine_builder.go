@@ -7,6 +7,7 @@ import (
 
 	"fq/internal/config"
 	"fq/internal/database"
+	"fq/internal/database/compute"
 	"fq/internal/database/storage"
 	inMemory "fq/internal/database/storage/engine/in-memory"
 	"fq/internal/database/storage/wal"
@@ -26,6 +27,8 @@ const (
 
 func CreateEngine(
 	cfg config.EngineConfig,
+	policies []database.Policy,
+	commands *compute.Registry,
 	logger *zerolog.Logger,
 	walStream <-chan []*wal.LogData,
 	dumpStream <-chan []database.DumpElem,
@@ -37,5 +40,17 @@ func CreateEngine(
 		}
 	}
 
-	return inMemory.NewEngine(inMemory.HashTableBuilder, defaultPartitionsNumber, logger, walStream, dumpStream)
+	tableBuilder := inMemory.NewHashTableBuilder(cfg.HistorySize)
+
+	engine, err := inMemory.NewEngine(tableBuilder, defaultPartitionsNumber, logger, walStream, dumpStream)
+	if err != nil {
+		return nil, err
+	}
+
+	engine.SetStaticPolicies(policies)
+	if err := engine.SetCommands(commands); err != nil {
+		return nil, err
+	}
+
+	return engine, nil
 }
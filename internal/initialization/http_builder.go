@@ -0,0 +1,40 @@
+package initialization
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"fq/internal/config"
+	"fq/internal/gateway"
+	"fq/internal/tools"
+)
+
+const defaultHTTPMaxBodySize = 64 << 10
+const defaultHTTPMaxWatchTimeout = time.Minute
+
+// CreateHTTPGateway creates the HTTP/JSON API, it is disabled without the http section.
+func CreateHTTPGateway(cfg *config.HTTPConfig, logger *zerolog.Logger) (*gateway.HTTPServer, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	maxBodySize := defaultHTTPMaxBodySize
+	maxWatchTimeout := defaultHTTPMaxWatchTimeout
+
+	if cfg.MaxBodySize != "" {
+		size, err := tools.ParseSize(cfg.MaxBodySize)
+		if err != nil {
+			return nil, errors.New("incorrect max body size")
+		}
+
+		maxBodySize = size
+	}
+
+	if cfg.MaxWatchTimeout != 0 {
+		maxWatchTimeout = cfg.MaxWatchTimeout
+	}
+
+	return gateway.NewHTTPServer(cfg.Address, maxBodySize, maxWatchTimeout, logger)
+}
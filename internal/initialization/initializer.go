@@ -14,17 +14,21 @@ import (
 	"fq/internal/database/storage/dumper"
 	"fq/internal/database/storage/replication"
 	walPkg "fq/internal/database/storage/wal"
+	"fq/internal/gateway"
 	"fq/internal/network"
 )
 
 type Initializer struct {
 	wal            *walPkg.WAL
 	engine         storage.Engine
+	commands       *compute.Registry
 	dumper         *dumper.Dumper
 	server         *network.TCPServer
+	respServer     *network.RESPServer
+	httpGateway    *gateway.HTTPServer
 	logger         *zerolog.Logger
-	slave          *replication.Slave
-	master         *replication.Master
+	replica        *replication.Node
+	forwarder      *replication.Forwarder
 	walStream      chan []*walPkg.LogData
 	dumpStream     chan []database.DumpElem
 	cfg            config.Config
@@ -40,12 +44,22 @@ func NewInitializer(cfg config.Config) (*Initializer, error) {
 		return nil, fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
-	wal, err := CreateWAL(cfg.WAL, logger, walStream)
+	// the WAL writer wakes up replication requests of slaves
+	walNotifier := walPkg.NewFlushNotifier()
+
+	wal, err := CreateWAL(cfg.WAL, logger, walStream, walNotifier)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize wal: %w", err)
 	}
 
-	dbEngine, err := CreateEngine(cfg.Engine, logger, walStream, dumpStream)
+	policies, err := CreatePolicies(cfg.Policies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize policies: %w", err)
+	}
+
+	commands := compute.NewRegistry()
+
+	dbEngine, err := CreateEngine(cfg.Engine, policies, commands, logger, walStream, dumpStream)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize engine: %w", err)
 	}
@@ -55,6 +69,16 @@ func NewInitializer(cfg config.Config) (*Initializer, error) {
 		return nil, fmt.Errorf("failed to initialize network: %w", err)
 	}
 
+	respServer, err := CreateRESPNetwork(cfg.RESP, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize resp network: %w", err)
+	}
+
+	httpGateway, err := CreateHTTPGateway(cfg.HTTP, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize http gateway: %w", err)
+	}
+
 	maxMessageSize, err := cfg.Network.ParseMaxMessageSize()
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse max message size: %w", err)
@@ -62,16 +86,25 @@ func NewInitializer(cfg config.Config) (*Initializer, error) {
 
 	dumpSrv := dumper.New(dbEngine, wal, cfg.Dump.Directory)
 
-	replica, err := CreateReplica(cfg.Replication, cfg.WAL, logger, dumpSrv, walStream, dumpStream)
+	replica, err := CreateReplica(cfg.Replication, cfg.WAL, logger, dumpSrv, walNotifier, walStream, dumpStream)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize replication: %w", err)
 	}
 
+	forwarder, err := CreateForwarder(cfg.Replication, maxMessageSize, cfg.Network.IdleTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize forwarder: %w", err)
+	}
+
 	initializer := &Initializer{
 		wal:            wal,
 		engine:         dbEngine,
+		commands:       commands,
 		dumper:         dumpSrv,
 		server:         tcpServer,
+		respServer:     respServer,
+		httpGateway:    httpGateway,
+		forwarder:      forwarder,
 		logger:         logger,
 		walStream:      walStream,
 		dumpStream:     dumpStream,
@@ -100,7 +133,16 @@ func (i *Initializer) StartDatabase(ctx context.Context) error {
 		close(i.dumpStream)
 	}()
 
-	db := database.NewDatabase(computeLayer, strg, i.logger, i.maxMessageSize)
+	db := database.NewDatabase(computeLayer, strg, i.commands, i.logger, i.maxMessageSize)
+	if i.forwarder != nil {
+		db.SetForwarder(i.forwarder)
+
+		defer func() {
+			if err := i.forwarder.Close(); err != nil {
+				i.logger.Warn().Err(err).Msg("failed to close forwarder")
+			}
+		}()
+	}
 
 	group, groupCtx := errgroup.WithContext(ctx)
 
@@ -121,26 +163,42 @@ func (i *Initializer) StartDatabase(ctx context.Context) error {
 		}
 	}
 
-	if i.master != nil {
-		group.Go(func() error {
-			return i.master.Start(groupCtx)
-		})
-	}
-
 	group.Go(func() error {
 		return i.server.HandleQueries(groupCtx, func(ctx context.Context, query []byte) ([]byte, error) {
-			response := db.HandleQuery(ctx, string(query))
+			if pusher, ok := network.PusherFromContext(ctx); ok {
+				ctx = database.ContextWithPusher(ctx, pusher)
+			}
+
+			if network.IsFramed(ctx) {
+				ctx = database.ContextWithFraming(ctx)
+			}
+
+			response := db.HandleMessage(ctx, string(query))
 
 			return []byte(response), nil
 		})
 	})
 
+	if i.respServer != nil {
+		group.Go(func() error {
+			return i.respServer.HandleCommands(groupCtx, func(ctx context.Context, args []string) network.RESPValue {
+				return makeRESPValue(db.HandleCommand(ctx, args))
+			})
+		})
+	}
+
+	if i.httpGateway != nil {
+		group.Go(func() error {
+			return i.httpGateway.HandleRequests(groupCtx, db)
+		})
+	}
+
 	return group.Wait()
 }
 
 func (i *Initializer) createComputeLayer() *compute.Compute {
 	queryParser := compute.NewParser(i.logger)
-	queryAnalyzer := compute.NewAnalyzer(i.logger)
+	queryAnalyzer := compute.NewAnalyzer(i.commands, i.logger)
 
 	return compute.NewCompute(queryParser, queryAnalyzer, i.logger)
 }
@@ -167,7 +225,7 @@ func (i *Initializer) createStorageLayer(ctx context.Context) (*storage.Storage,
 	return strg, nil
 }
 
-func (i *Initializer) initializeReplication(replica interface{}) {
+func (i *Initializer) initializeReplication(replica *replication.Node) {
 	if replica == nil {
 		return
 	}
@@ -178,20 +236,13 @@ func (i *Initializer) initializeReplication(replica interface{}) {
 		return
 	}
 
-	switch v := replica.(type) {
-	case *replication.Slave:
-		i.slave = v
-	case *replication.Master:
-		i.master = v
-	default:
-		i.logger.Error().Msg("incorrect replication type")
-	}
+	i.replica = replica
 }
 
 func (i *Initializer) storageReplicaSlave() storage.Replica {
-	if i.slave == nil {
+	if i.replica == nil {
 		return nil
 	}
 
-	return i.slave
+	return i.replica
 }
@@ -17,6 +17,29 @@ const defaultMaxMessageSize = 4096
 const defaultIdleTimeout = time.Minute * 5
 
 func CreateNetwork(cfg config.NetworkConfig, logger *zerolog.Logger) (*network.TCPServer, error) {
+	address, maxConnectionsNumber, maxMessageSize, idleTimeout, err := networkSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return network.NewTCPServer(address, maxConnectionsNumber, maxMessageSize, idleTimeout, logger)
+}
+
+// CreateRESPNetwork creates the RESP listener, it is disabled without the resp section.
+func CreateRESPNetwork(cfg *config.NetworkConfig, logger *zerolog.Logger) (*network.RESPServer, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	address, maxConnectionsNumber, maxMessageSize, idleTimeout, err := networkSettings(*cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return network.NewRESPServer(address, maxConnectionsNumber, maxMessageSize, idleTimeout, logger)
+}
+
+func networkSettings(cfg config.NetworkConfig) (string, int, int, time.Duration, error) {
 	address := defaultServerAddress
 	maxConnectionsNumber := defaultMaxConnectionNumber
 	maxMessageSize := defaultMaxMessageSize
@@ -33,7 +56,7 @@ func CreateNetwork(cfg config.NetworkConfig, logger *zerolog.Logger) (*network.T
 	if cfg.MaxMessageSize != "" {
 		size, err := tools.ParseSize(cfg.MaxMessageSize)
 		if err != nil {
-			return nil, errors.New("incorrect max message size")
+			return "", 0, 0, 0, errors.New("incorrect max message size")
 		}
 
 		maxMessageSize = size
@@ -43,5 +66,5 @@ func CreateNetwork(cfg config.NetworkConfig, logger *zerolog.Logger) (*network.T
 		idleTimeout = cfg.IdleTimeout
 	}
 
-	return network.NewTCPServer(address, maxConnectionsNumber, maxMessageSize, idleTimeout, logger)
+	return address, maxConnectionsNumber, maxMessageSize, idleTimeout, nil
 }
@@ -0,0 +1,36 @@
+package initialization
+
+import (
+	"fmt"
+
+	"fq/internal/config"
+	"fq/internal/database"
+)
+
+func CreatePolicies(cfg map[string]config.PolicyConfig) ([]database.Policy, error) {
+	policies := make([]database.Policy, 0, len(cfg))
+
+	for name, policyCfg := range cfg {
+		window, err := database.ParseWindow(policyCfg.Window)
+		if err != nil {
+			return nil, fmt.Errorf("policy %q: %w", name, err)
+		}
+
+		var mode database.WindowMode
+		if policyCfg.Mode != "" {
+			if mode, err = database.ParseWindowMode(policyCfg.Mode); err != nil {
+				return nil, fmt.Errorf("policy %q: %w", name, err)
+			}
+		}
+
+		policies = append(policies, database.Policy{
+			Name:   name,
+			Window: window,
+			Limit:  database.ValueType(policyCfg.Limit),
+			Mode:   mode,
+			Static: true,
+		})
+	}
+
+	return policies, nil
+}
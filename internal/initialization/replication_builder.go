@@ -18,16 +18,19 @@ const defaultReplicationType = "master"
 const defaultReplicationMasterAddress = ":1946"
 const defaultReplicationSyncInterval = time.Second
 
+// CreateReplica creates the replication node, its role can be changed by the REPLICA command.
 func CreateReplica(
 	replicationCfg config.ReplicationConfig,
 	walCfg *config.WALConfig,
 	logger *zerolog.Logger,
 	dumperSrv *dumper.Dumper,
+	walNotifier *wal.FlushNotifier,
 	walStream chan<- []*wal.LogData,
 	dumpStream chan<- []database.DumpElem,
-) (interface{}, error) {
+) (*replication.Node, error) {
 	replicaType := defaultReplicationType
 	masterAddress := defaultReplicationMasterAddress
+	listenAddress := defaultReplicationMasterAddress
 	syncInterval := defaultReplicationSyncInterval
 	walDirectory := defaultWALDataDirectory
 
@@ -43,6 +46,10 @@ func CreateReplica(
 		masterAddress = replicationCfg.MasterAddress
 	}
 
+	if replicationCfg.ListenAddress != "" {
+		listenAddress = replicationCfg.ListenAddress
+	}
+
 	if replicationCfg.SyncInterval != 0 {
 		syncInterval = replicationCfg.SyncInterval
 	}
@@ -51,23 +58,78 @@ func CreateReplica(
 		walDirectory = walCfg.DataDirectory
 	}
 
-	const maxReplicasNumber = 5
-	const maxMessageSize = 16 << 20
-	idleTimeout := syncInterval * 3
+	factory := &replicaFactory{
+		walDirectory: walDirectory,
+		syncInterval: syncInterval,
+		dumper:       dumperSrv,
+		walNotifier:  walNotifier,
+		walStream:    walStream,
+		dumpStream:   dumpStream,
+		logger:       logger,
+	}
 
 	if replicaType == "master" {
-		server, err := network.NewTCPServer(masterAddress, maxReplicasNumber, maxMessageSize, idleTimeout, logger)
-		if err != nil {
-			return nil, err
-		}
+		factory.listenAddress = masterAddress
+
+		return replication.NewNode(factory, "", walDirectory, logger)
+	}
+
+	factory.listenAddress = listenAddress
+
+	return replication.NewNode(factory, masterAddress, walDirectory, logger)
+}
+
+// replicaFactory makes the master and slaves of the replication node.
+type replicaFactory struct {
+	listenAddress string
+	walDirectory  string
+	syncInterval  time.Duration
+	dumper        *dumper.Dumper
+	walNotifier   *wal.FlushNotifier
+	walStream     chan<- []*wal.LogData
+	dumpStream    chan<- []database.DumpElem
+	logger        *zerolog.Logger
+}
+
+const maxReplicasNumber = 5
+const maxReplicationMessageSize = 16 << 20
 
-		return replication.NewMaster(server, walDirectory, dumperSrv, logger)
+func (f *replicaFactory) NewMaster() (*replication.Master, error) {
+	server, err := network.NewTCPServer(f.listenAddress, maxReplicasNumber, maxReplicationMessageSize, f.syncInterval*3, f.logger)
+	if err != nil {
+		return nil, err
 	}
 
+	master, err := replication.NewMaster(server, f.walDirectory, f.dumper, f.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	master.SetFlushNotifier(f.walNotifier)
+
+	return master, nil
+}
+
+func (f *replicaFactory) NewSlave(masterAddress string) (*replication.Slave, error) {
 	// Create client factory for reconnection support
-	clientFactory := replication.NewTCPClientFactory(masterAddress, maxMessageSize, idleTimeout)
+	clientFactory := replication.NewTCPClientFactory(masterAddress, maxReplicationMessageSize, f.syncInterval*3)
+
+	fsReader := wal.NewFSReader(f.walDirectory, f.logger)
+
+	return replication.NewSlaveWithFactory(clientFactory, fsReader, f.walStream, f.dumpStream, f.walDirectory, f.syncInterval, f.logger)
+}
+
+// CreateForwarder creates the forwarder of writes of a slave configured with slave_writes: forward.
+func CreateForwarder(
+	replicationCfg config.ReplicationConfig,
+	maxMessageSize int,
+	idleTimeout time.Duration,
+) (*replication.Forwarder, error) {
+	if replicationCfg.ReplicaType != "slave" || replicationCfg.SlaveWrites != config.SlaveWritesForward {
+		return nil, nil
+	}
 
-	fsReader := wal.NewFSReader(walDirectory, logger)
+	clientFactory := replication.NewTCPClientFactory(replicationCfg.ForwardAddress, maxMessageSize, idleTimeout)
 
-	return replication.NewSlaveWithFactory(clientFactory, fsReader, walStream, dumpStream, walDirectory, syncInterval, logger)
+	return replication.NewForwarder(clientFactory)
 }
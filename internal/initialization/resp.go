@@ -0,0 +1,40 @@
+package initialization
+
+import (
+	"fq/internal/database"
+	"fq/internal/network"
+)
+
+// makeRESPValue encodes a reply for RESP clients: a single value as an integer or a bulk string,
+// several values and lists as arrays and errors prefixed by their codes.
+func makeRESPValue(reply database.Reply) network.RESPValue {
+	if reply.Err != nil {
+		return network.RESPErrorValue(string(database.ErrorCodeOf(reply.Err)) + " " + reply.Err.Error())
+	}
+
+	if len(reply.Values) == 1 && !reply.Array {
+		return makeRESPReplyValue(reply.Values[0])
+	}
+
+	return makeRESPArray(reply.Values)
+}
+
+func makeRESPReplyValue(value database.ReplyValue) network.RESPValue {
+	switch value.Kind {
+	case database.ReplyValueString:
+		return network.RESPBulkStringValue(value.Str)
+	case database.ReplyValueTuple:
+		return makeRESPArray(value.Tuple)
+	default:
+		return network.RESPIntegerValue(value.Int)
+	}
+}
+
+func makeRESPArray(values []database.ReplyValue) network.RESPValue {
+	array := make([]network.RESPValue, 0, len(values))
+	for _, value := range values {
+		array = append(array, makeRESPReplyValue(value))
+	}
+
+	return network.RESPArrayValue(array...)
+}
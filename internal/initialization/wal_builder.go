@@ -20,6 +20,7 @@ func CreateWAL(
 	cfg *config.WALConfig,
 	logger *zerolog.Logger,
 	stream chan<- []*wal.LogData,
+	notifier *wal.FlushNotifier,
 ) (*wal.WAL, error) {
 	flushingBatchSize := defaultFlushingBatchSize
 	flushingBatchTimeout := defaultFlushingBatchTimeout
@@ -50,6 +51,7 @@ func CreateWAL(
 
 		fsReader := wal.NewFSReader(dataDirectory, logger)
 		fsWriter := wal.NewFSWriter(dataDirectory, maxSegmentSize, logger)
+		fsWriter.SetFlushNotifier(notifier)
 
 		return wal.NewWAL(
 			fsWriter,
@@ -0,0 +1,93 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Framed protocol: a client starts a connection with the handshake, the server acknowledges it
+// by sending the handshake back. Then every message in both directions is a frame:
+// [version: 1 byte][payload length: uint32, big endian][payload].
+// A connection which doesn't start with the handshake uses the unframed protocol,
+// where every read is a message.
+
+const (
+	// FrameVersion is the version of the framed protocol.
+	FrameVersion byte = 1
+
+	frameHeaderSize = 5
+	handshakeSize   = 4 // magic and version
+)
+
+// handshakeMagic starts the handshake, the zero byte never starts an unframed query.
+var handshakeMagic = []byte{0x00, 'F', 'Q'}
+
+var (
+	ErrInvalidFrame          = errors.New("invalid frame")
+	ErrFrameTooLarge         = errors.New("frame exceeds max message size")
+	ErrUnsupportedVersion    = errors.New("unsupported protocol version")
+	errHandshakeNotConfirmed = errors.New("handshake is not confirmed")
+)
+
+func makeHandshake(version byte) []byte {
+	handshake := make([]byte, 0, handshakeSize)
+	handshake = append(handshake, handshakeMagic...)
+
+	return append(handshake, version)
+}
+
+// parseHandshake returns the protocol version of a complete handshake.
+func parseHandshake(handshake []byte) (byte, error) {
+	if len(handshake) != handshakeSize || !bytes.Equal(handshake[:len(handshakeMagic)], handshakeMagic) {
+		return 0, errHandshakeNotConfirmed
+	}
+
+	version := handshake[len(handshakeMagic)]
+	if version != FrameVersion {
+		return 0, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+
+	return version, nil
+}
+
+// makeFrame prepends the header to the payload.
+func makeFrame(payload []byte) []byte {
+	frame := make([]byte, frameHeaderSize+len(payload))
+	frame[0] = FrameVersion
+	binary.BigEndian.PutUint32(frame[1:frameHeaderSize], uint32(len(payload))) //nolint:gosec // messages are limited in size
+	copy(frame[frameHeaderSize:], payload)
+
+	return frame
+}
+
+// readFrame reads a frame and returns its payload. The payload is read into buff when it fits.
+func readFrame(reader io.Reader, buff []byte, maxSize int) ([]byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return nil, err
+	}
+
+	if header[0] != FrameVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrInvalidFrame, header[0])
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if uint64(size) > uint64(maxSize) {
+		return nil, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, size, maxSize)
+	}
+
+	payload := buff
+	if int(size) > len(payload) {
+		payload = make([]byte, size)
+	}
+
+	payload = payload[:size]
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
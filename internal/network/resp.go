@@ -0,0 +1,186 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RESP protocol versions, a connection starts with RESP2 and switches to RESP3 by HELLO.
+const (
+	RESP2 = 2
+	RESP3 = 3
+)
+
+var ErrRESPProtocol = errors.New("RESP protocol error")
+
+// RESPKind is a type of a RESP value.
+type RESPKind uint8
+
+const (
+	RESPSimpleString RESPKind = iota
+	RESPError
+	RESPInteger
+	RESPBulkString
+	RESPArray
+	// RESPMap keeps keys and values one after another in Array, RESP2 encodes it as an array
+	RESPMap
+	RESPNull
+)
+
+// RESPValue is a reply of the RESP protocol.
+type RESPValue struct {
+	Kind  RESPKind
+	Int   int64
+	Str   string
+	Array []RESPValue
+}
+
+func RESPSimpleStringValue(v string) RESPValue {
+	return RESPValue{Kind: RESPSimpleString, Str: v}
+}
+
+// RESPErrorValue makes an error, its message starts with an error code, e.g. "ERR unknown command".
+func RESPErrorValue(message string) RESPValue {
+	return RESPValue{Kind: RESPError, Str: message}
+}
+
+func RESPIntegerValue(v int64) RESPValue {
+	return RESPValue{Kind: RESPInteger, Int: v}
+}
+
+func RESPBulkStringValue(v string) RESPValue {
+	return RESPValue{Kind: RESPBulkString, Str: v}
+}
+
+func RESPArrayValue(values ...RESPValue) RESPValue {
+	return RESPValue{Kind: RESPArray, Array: values}
+}
+
+// RESPMapValue makes a map of keys and values given one after another.
+func RESPMapValue(keysAndValues ...RESPValue) RESPValue {
+	return RESPValue{Kind: RESPMap, Array: keysAndValues}
+}
+
+func RESPNullValue() RESPValue {
+	return RESPValue{Kind: RESPNull}
+}
+
+// appendRESP encodes the value with the protocol version.
+func (v RESPValue) appendRESP(dst []byte, version int) []byte {
+	switch v.Kind {
+	case RESPSimpleString:
+		dst = append(dst, '+')
+		dst = append(dst, sanitizeRESPLine(v.Str)...)
+	case RESPError:
+		dst = append(dst, '-')
+		dst = append(dst, sanitizeRESPLine(v.Str)...)
+	case RESPInteger:
+		dst = append(dst, ':')
+		dst = strconv.AppendInt(dst, v.Int, 10)
+	case RESPBulkString:
+		dst = append(dst, '$')
+		dst = strconv.AppendInt(dst, int64(len(v.Str)), 10)
+		dst = append(dst, '\r', '\n')
+		dst = append(dst, v.Str...)
+	case RESPArray, RESPMap:
+		if v.Kind == RESPMap && version >= RESP3 {
+			dst = append(dst, '%')
+			dst = strconv.AppendInt(dst, int64(len(v.Array)/2), 10)
+		} else {
+			dst = append(dst, '*')
+			dst = strconv.AppendInt(dst, int64(len(v.Array)), 10)
+		}
+
+		dst = append(dst, '\r', '\n')
+		for _, item := range v.Array {
+			dst = item.appendRESP(dst, version)
+		}
+
+		return dst
+	case RESPNull:
+		if version >= RESP3 {
+			dst = append(dst, '_')
+		} else {
+			dst = append(dst, '$', '-', '1')
+		}
+	}
+
+	return append(dst, '\r', '\n')
+}
+
+// sanitizeRESPLine removes line breaks, which would break simple strings and errors.
+func sanitizeRESPLine(str string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(str)
+}
+
+// readRESPCommand reads a command sent as an array of bulk strings or as an inline command.
+// A command is limited by maxSize bytes of its arguments.
+func readRESPCommand(reader *bufio.Reader, maxSize int) ([]string, error) {
+	line, err := readRESPLine(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil || count > maxSize {
+		return nil, fmt.Errorf("%w: invalid multibulk length", ErrRESPProtocol)
+	}
+
+	args := make([]string, 0, max(count, 0))
+	size := 0
+	for i := 0; i < count; i++ {
+		line, err = readRESPLine(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got %q", ErrRESPProtocol, line)
+		}
+
+		length, err := strconv.Atoi(line[1:])
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("%w: invalid bulk length", ErrRESPProtocol)
+		}
+
+		size += length
+		if size > maxSize {
+			return nil, fmt.Errorf("%w: command size exceeds maximum %d", ErrRESPProtocol, maxSize)
+		}
+
+		arg := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+
+		if arg[length] != '\r' || arg[length+1] != '\n' {
+			return nil, fmt.Errorf("%w: bulk string is not terminated", ErrRESPProtocol)
+		}
+
+		args = append(args, string(arg[:length]))
+	}
+
+	return args, nil
+}
+
+// readRESPLine reads a line terminated by CRLF (or LF for inline commands), it is limited by the buffer size.
+func readRESPLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return "", fmt.Errorf("%w: line is too long", ErrRESPProtocol)
+		}
+
+		return "", err
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r"), nil
+}
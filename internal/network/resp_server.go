@@ -0,0 +1,192 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"fq/internal/tools"
+)
+
+// RESPHandler handles a command and its arguments decoded from a RESP request.
+type RESPHandler = func(context.Context, []string) RESPValue
+
+const (
+	respHelloCommand = "HELLO"
+	respPingCommand  = "PING"
+	respQuitCommand  = "QUIT"
+
+	respServerName = "fq"
+)
+
+// RESPServer serves clients of the Redis serialization protocol (RESP2 and RESP3).
+// HELLO, PING and QUIT are handled by the server, other commands are passed to the handler.
+type RESPServer struct {
+	address     string
+	semaphore   tools.Semaphore
+	idleTimeout time.Duration
+	messageSize int
+	logger      *zerolog.Logger
+}
+
+func NewRESPServer(
+	address string,
+	maxConnectionsNumber int,
+	maxMessageSize int,
+	idleTimeout time.Duration,
+	logger *zerolog.Logger,
+) (*RESPServer, error) {
+	if logger == nil {
+		return nil, errors.New("logger is invalid")
+	}
+
+	if maxConnectionsNumber <= 0 {
+		return nil, errors.New("invalid number of max connections")
+	}
+
+	return &RESPServer{
+		address:     address,
+		semaphore:   tools.NewSemaphore(maxConnectionsNumber),
+		idleTimeout: idleTimeout,
+		messageSize: maxMessageSize,
+		logger:      logger,
+	}, nil
+}
+
+func (s *RESPServer) HandleCommands(ctx context.Context, handler RESPHandler) error {
+	return serve(ctx, s.address, s.semaphore, s.logger, func(connection net.Conn) {
+		s.handleConnection(ctx, connection, handler)
+	})
+}
+
+func (s *RESPServer) handleConnection(ctx context.Context, connection net.Conn, handler RESPHandler) {
+	// the buffer limits lines of requests, arguments are limited by the max message size
+	reader := bufio.NewReaderSize(connection, s.messageSize)
+	writer := bufio.NewWriter(connection)
+	version := RESP2
+
+	for {
+		// replies to pipelined requests are sent together, before waiting for new requests
+		if reader.Buffered() == 0 {
+			if err := s.flush(connection, writer); err != nil {
+				s.logger.Warn().Err(err).Msg("failed to write")
+
+				break
+			}
+		}
+
+		if err := connection.SetReadDeadline(time.Now().Add(s.idleTimeout)); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to set read deadline")
+
+			break
+		}
+
+		args, err := connRead(ctx, connection, func() ([]string, error) {
+			return readRESPCommand(reader, s.messageSize)
+		})
+		if err != nil {
+			if errors.Is(err, ErrRESPProtocol) {
+				_ = s.write(connection, writer, RESPErrorValue("ERR "+err.Error()), version)
+			} else if err != io.EOF {
+				s.logger.Warn().Err(err).Msg("failed to read")
+			}
+
+			break
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		var reply RESPValue
+		quit := false
+
+		switch strings.ToUpper(args[0]) {
+		case respHelloCommand:
+			reply = s.hello(args[1:], &version)
+		case respPingCommand:
+			reply = ping(args[1:])
+		case respQuitCommand:
+			reply = RESPSimpleStringValue("OK")
+			quit = true
+		default:
+			reply = handler(ctx, args)
+		}
+
+		if err := s.write(connection, writer, reply, version); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to write")
+
+			break
+		}
+
+		if quit {
+			break
+		}
+	}
+
+	if err := s.flush(connection, writer); err != nil {
+		s.logger.Warn().Err(err).Msg("failed to write")
+	}
+
+	s.logger.Warn().Msg("close connection")
+
+	if err := connection.Close(); err != nil {
+		s.logger.Warn().Err(err).Msg("failed to close connection")
+	}
+}
+
+// write buffers the reply, the buffer is written to the connection when it is full or flushed.
+func (s *RESPServer) write(connection net.Conn, writer *bufio.Writer, reply RESPValue, version int) error {
+	if err := connection.SetWriteDeadline(time.Now().Add(s.idleTimeout)); err != nil {
+		return err
+	}
+
+	_, err := writer.Write(reply.appendRESP(nil, version))
+
+	return err
+}
+
+func (s *RESPServer) flush(connection net.Conn, writer *bufio.Writer) error {
+	if writer.Buffered() == 0 {
+		return nil
+	}
+
+	if err := connection.SetWriteDeadline(time.Now().Add(s.idleTimeout)); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// hello switches the protocol version: HELLO [protover [AUTH username password] [SETNAME clientname]].
+func (s *RESPServer) hello(args []string, version *int) RESPValue {
+	if len(args) > 0 {
+		requested, err := strconv.Atoi(args[0])
+		if err != nil || (requested != RESP2 && requested != RESP3) {
+			return RESPErrorValue("NOPROTO unsupported protocol version")
+		}
+
+		*version = requested
+	}
+
+	return RESPMapValue(
+		RESPBulkStringValue("server"), RESPBulkStringValue(respServerName),
+		RESPBulkStringValue("proto"), RESPIntegerValue(int64(*version)),
+		RESPBulkStringValue("mode"), RESPBulkStringValue("standalone"),
+	)
+}
+
+func ping(args []string) RESPValue {
+	if len(args) > 0 {
+		return RESPBulkStringValue(args[0])
+	}
+
+	return RESPSimpleStringValue("PONG")
+}
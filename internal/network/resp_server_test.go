@@ -0,0 +1,51 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRESPServer(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := zerolog.Nop()
+	server, err := NewRESPServer(":20004", 10, 2048, time.Minute, &logger)
+	require.NoError(t, err)
+
+	go func() {
+		require.NoError(t, server.HandleCommands(ctx, func(ctx context.Context, args []string) RESPValue {
+			if args[0] == "INCR" {
+				return RESPIntegerValue(1)
+			}
+
+			return RESPErrorValue("ERR unknown command")
+		}))
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	connection, err := net.Dial("tcp", "localhost:20004")
+	require.NoError(t, err)
+
+	// pipelined requests get replies in order
+	_, err = connection.Write([]byte("*3\r\n$4\r\nINCR\r\n$3\r\nkey\r\n$3\r\n600\r\nPING\r\n*2\r\n$5\r\nHELLO\r\n$1\r\n3\r\nUNKNOWN\r\nQUIT\r\n"))
+	require.NoError(t, err)
+
+	expected := ":1\r\n+PONG\r\n%3\r\n$6\r\nserver\r\n$2\r\nfq\r\n$5\r\nproto\r\n:3\r\n$4\r\nmode\r\n$10\r\nstandalone\r\n" +
+		"-ERR unknown command\r\n+OK\r\n"
+
+	// QUIT closes the connection after the replies
+	replies, err := io.ReadAll(bufio.NewReader(connection))
+	require.NoError(t, err)
+	require.Equal(t, expected, string(replies))
+}
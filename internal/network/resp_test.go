@@ -0,0 +1,68 @@
+package network
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRESPCommand(t *testing.T) {
+	tests := map[string]struct {
+		request string
+		args    []string
+		err     error
+	}{
+		"array":               {request: "*4\r\n$4\r\nINCR\r\n$3\r\nkey\r\n$3\r\n600\r\n$0\r\n\r\n", args: []string{"INCR", "key", "600", ""}},
+		"argument with space": {request: "*2\r\n$3\r\nGET\r\n$7\r\nkey one\r\n", args: []string{"GET", "key one"}},
+		"inline":              {request: "INCR key 600\r\n", args: []string{"INCR", "key", "600"}},
+		"inline with lf":      {request: "PING\n", args: []string{"PING"}},
+		"empty array":         {request: "*0\r\n", args: []string{}},
+		"not a bulk string":   {request: "*1\r\n:1\r\n", err: ErrRESPProtocol},
+		"invalid length":      {request: "*x\r\n", err: ErrRESPProtocol},
+		"unterminated bulk":   {request: "*1\r\n$3\r\nGETX\r\n", err: ErrRESPProtocol},
+		"too large":           {request: "*1\r\n$100\r\n", err: ErrRESPProtocol},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			args, err := readRESPCommand(bufio.NewReader(strings.NewReader(test.request)), 64)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.args, args)
+		})
+	}
+}
+
+func TestRESPValue_AppendRESP(t *testing.T) {
+	hello := RESPMapValue(RESPBulkStringValue("proto"), RESPIntegerValue(3))
+
+	tests := map[string]struct {
+		value    RESPValue
+		version  int
+		expected string
+	}{
+		"integer":       {value: RESPIntegerValue(-5), version: RESP2, expected: ":-5\r\n"},
+		"bulk string":   {value: RESPBulkStringValue("1d"), version: RESP2, expected: "$2\r\n1d\r\n"},
+		"error":         {value: RESPErrorValue("ERR bad\nline"), version: RESP2, expected: "-ERR bad line\r\n"},
+		"array":         {value: RESPArrayValue(RESPIntegerValue(1), RESPArrayValue()), version: RESP2, expected: "*2\r\n:1\r\n*0\r\n"},
+		"map in resp2":  {value: hello, version: RESP2, expected: "*2\r\n$5\r\nproto\r\n:3\r\n"},
+		"map in resp3":  {value: hello, version: RESP3, expected: "%1\r\n$5\r\nproto\r\n:3\r\n"},
+		"null in resp2": {value: RESPNullValue(), version: RESP2, expected: "$-1\r\n"},
+		"null in resp3": {value: RESPNullValue(), version: RESP3, expected: "_\r\n"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, string(test.value.appendRESP(nil, test.version)))
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package network
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"time"
 )
@@ -12,20 +15,77 @@ type TCPClient struct {
 	maxMessageSize int
 	idleTimeout    time.Duration
 	bufferPool     *bytesPool
+	framed         bool
 }
 
+// NewTCPClient connects to the server and negotiates the framed protocol.
+// A server which doesn't confirm the handshake is talked to with the unframed protocol.
 func NewTCPClient(address string, maxMessageSize int, idleTimeout time.Duration) (*TCPClient, error) {
 	connection, err := net.Dial("tcp", address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial: %w", err)
 	}
 
-	return &TCPClient{
+	client := &TCPClient{
 		connection:     connection,
 		maxMessageSize: maxMessageSize,
 		idleTimeout:    idleTimeout,
 		bufferPool:     newBytesPool(maxMessageSize),
-	}, nil
+	}
+
+	if err := client.handshake(); err != nil {
+		_ = connection.Close()
+
+		return nil, fmt.Errorf("failed to handshake: %w", err)
+	}
+
+	return client, nil
+}
+
+func (c *TCPClient) handshake() error {
+	if err := c.connection.SetDeadline(time.Now().Add(c.idleTimeout)); err != nil {
+		return err
+	}
+
+	handshake := makeHandshake(FrameVersion)
+	if _, err := c.connection.Write(handshake); err != nil {
+		return err
+	}
+
+	response := c.bufferPool.Get()
+	defer c.bufferPool.Put(response)
+
+	count, err := c.connection.Read(response)
+	if err != nil {
+		return err
+	}
+
+	// the confirmation may arrive in parts
+	if count < handshakeSize && bytes.Equal(response[:count], handshake[:count]) {
+		if _, err := io.ReadFull(c.connection, response[count:handshakeSize]); err != nil {
+			return err
+		}
+
+		count = handshakeSize
+	}
+
+	// servers without framing answer the handshake with an error
+	if _, err := parseHandshake(response[:count]); err != nil {
+		if errors.Is(err, ErrUnsupportedVersion) {
+			return err
+		}
+
+		return nil
+	}
+
+	c.framed = true
+
+	return nil
+}
+
+// Framed reports whether the server confirmed the framed protocol.
+func (c *TCPClient) Framed() bool {
+	return c.framed
 }
 
 func (c *TCPClient) Send(ctx context.Context, request []byte) ([]byte, error) {
@@ -37,20 +97,44 @@ func (c *TCPClient) Send(ctx context.Context, request []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	if c.framed {
+		request = makeFrame(request)
+	}
+
 	if _, err := c.connection.Write(request); err != nil {
 		return nil, err
 	}
 
-	response := c.bufferPool.Get()
-	defer c.bufferPool.Put(response)
+	return c.read()
+}
 
-	count, err := c.connection.Read(response)
+// Receive waits for a message pushed by the server, e.g. an event of a subscription.
+// It waits without a timeout unless the context has a deadline.
+func (c *TCPClient) Receive(ctx context.Context) ([]byte, error) {
+	deadline, _ := ctx.Deadline()
+	if err := c.connection.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	return c.read()
+}
+
+// read reads a message: a frame or, without framing, whatever a single read returns.
+func (c *TCPClient) read() ([]byte, error) {
+	if c.framed {
+		return readFrame(c.connection, nil, c.maxMessageSize)
+	}
+
+	message := c.bufferPool.Get()
+	defer c.bufferPool.Put(message)
+
+	count, err := c.connection.Read(message)
 	if err != nil {
 		return nil, err
 	}
 
 	result := make([]byte, count)
-	copy(result, response[:count])
+	copy(result, message[:count])
 
 	return result, nil
 }
@@ -2,6 +2,7 @@ package network
 
 import (
 	"context"
+	"io"
 	"net"
 	"reflect"
 	"testing"
@@ -25,12 +26,13 @@ func TestTCPClient(t *testing.T) {
 			return
 		}
 
-		buffer := make([]byte, 2048)
-		count, err := connection.Read(buffer)
+		acceptHandshake(t, connection)
+
+		buffer, err := readFrame(connection, nil, 2048)
 		require.NoError(t, err)
-		require.True(t, reflect.DeepEqual([]byte(request), buffer[:count]))
+		require.True(t, reflect.DeepEqual([]byte(request), buffer))
 
-		_, err = connection.Write([]byte(response))
+		_, err = connection.Write(makeFrame([]byte(response)))
 		require.NoError(t, err)
 
 		defer func() {
@@ -46,11 +48,73 @@ func TestTCPClient(t *testing.T) {
 	client, err := NewTCPClient("127.0.0.1:10001", 2048, time.Minute)
 	require.NoError(t, err)
 
+	require.True(t, client.Framed())
+
 	buffer, err := client.Send(context.Background(), []byte(request))
 	require.NoError(t, err)
 	require.True(t, reflect.DeepEqual([]byte(response), buffer))
 }
 
+func TestTCPClientUnframedServer(t *testing.T) {
+	t.Parallel()
+
+	request := "hello server"
+	response := "hello client"
+
+	listener, err := net.Listen("tcp", ":10003")
+	require.NoError(t, err)
+
+	go func() {
+		connection, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer func() {
+			require.NoError(t, connection.Close())
+			require.NoError(t, listener.Close())
+		}()
+
+		// a server without framing answers the handshake as a query
+		buffer := make([]byte, 2048)
+		_, err = connection.Read(buffer)
+		require.NoError(t, err)
+		_, err = connection.Write([]byte("err|invalid symbol"))
+		require.NoError(t, err)
+
+		count, err := connection.Read(buffer)
+		require.NoError(t, err)
+		require.Equal(t, request, string(buffer[:count]))
+
+		_, err = connection.Write([]byte(response))
+		require.NoError(t, err)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	client, err := NewTCPClient("127.0.0.1:10003", 2048, time.Minute)
+	require.NoError(t, err)
+	require.False(t, client.Framed())
+
+	buffer, err := client.Send(context.Background(), []byte(request))
+	require.NoError(t, err)
+	require.Equal(t, []byte(response), buffer)
+}
+
+func acceptHandshake(t *testing.T, connection net.Conn) {
+	t.Helper()
+
+	handshake := make([]byte, handshakeSize)
+	_, err := io.ReadFull(connection, handshake)
+	require.NoError(t, err)
+
+	version, err := parseHandshake(handshake)
+	require.NoError(t, err)
+
+	_, err = connection.Write(makeHandshake(version))
+	require.NoError(t, err)
+}
+
 func TestTCPIdleClientConnection(t *testing.T) {
 	t.Parallel()
 
@@ -68,10 +132,11 @@ func TestTCPIdleClientConnection(t *testing.T) {
 			return
 		}
 
-		buffer := make([]byte, 2048)
-		count, err := connection.Read(buffer)
+		acceptHandshake(t, connection)
+
+		buffer, err := readFrame(connection, nil, 2048)
 		require.NoError(t, err)
-		require.True(t, reflect.DeepEqual([]byte(request), buffer[:count]))
+		require.True(t, reflect.DeepEqual([]byte(request), buffer))
 
 		<-ctx.Done()
 		defer func() {
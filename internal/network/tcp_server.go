@@ -1,12 +1,14 @@
 package network
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -16,6 +18,34 @@ import (
 
 type TCPHandler = func(context.Context, []byte) ([]byte, error)
 
+// Pusher writes messages initiated by the server to a connection, e.g. events of subscriptions.
+// Handlers get the pusher of the connection from the context by PusherFromContext.
+type Pusher interface {
+	// Push writes a message to the connection, it is safe to call concurrently with responses.
+	Push(message []byte) error
+	// Hold keeps the connection open while it is idle until release is called.
+	Hold() (release func())
+}
+
+type pusherCtxKey struct{}
+
+// PusherFromContext returns the pusher of the connection of a handled request.
+// The context of a request is canceled when its connection is closed.
+func PusherFromContext(ctx context.Context) (Pusher, bool) {
+	pusher, ok := ctx.Value(pusherCtxKey{}).(Pusher)
+
+	return pusher, ok
+}
+
+type framedCtxKey struct{}
+
+// IsFramed reports whether the connection of a handled request speaks the framed protocol.
+func IsFramed(ctx context.Context) bool {
+	conn, ok := ctx.Value(framedCtxKey{}).(*serverConnection)
+
+	return ok && conn.framed.Load()
+}
+
 type TCPServer struct {
 	address     string
 	semaphore   tools.Semaphore
@@ -49,7 +79,20 @@ func NewTCPServer(
 }
 
 func (s *TCPServer) HandleQueries(ctx context.Context, handler TCPHandler) error {
-	listener, err := net.Listen("tcp", s.address)
+	return serve(ctx, s.address, s.semaphore, s.logger, func(connection net.Conn) {
+		s.handleConnection(ctx, connection, handler)
+	})
+}
+
+// serve accepts connections until the context is canceled and waits for their handlers.
+func serve(
+	ctx context.Context,
+	address string,
+	semaphore tools.Semaphore,
+	logger *zerolog.Logger,
+	handle func(net.Conn),
+) error {
+	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
@@ -67,23 +110,23 @@ func (s *TCPServer) HandleQueries(ctx context.Context, handler TCPHandler) error
 					return
 				}
 
-				s.logger.Error().Err(err).Msg("failed to accept")
+				logger.Error().Err(err).Msg("failed to accept")
 
 				continue
 			}
 
-			s.logger.Info().Msg("accepted connection")
+			logger.Info().Msg("accepted connection")
 
 			wg.Add(1)
 			go func(connection net.Conn) {
-				s.semaphore.Acquire()
+				semaphore.Acquire()
 
 				defer func() {
-					s.semaphore.Release()
+					semaphore.Release()
 					wg.Done()
 				}()
 
-				s.handleConnection(ctx, connection, handler)
+				handle(connection)
 			}(connection)
 		}
 	}()
@@ -93,7 +136,7 @@ func (s *TCPServer) HandleQueries(ctx context.Context, handler TCPHandler) error
 
 		<-ctx.Done()
 		if err := listener.Close(); err != nil {
-			s.logger.Warn().Err(err).Msg("failed to close listener")
+			logger.Warn().Err(err).Msg("failed to close listener")
 		}
 	}()
 
@@ -108,15 +151,29 @@ func (s *TCPServer) Start(ctx context.Context, handler func(context.Context, []b
 
 func (s *TCPServer) handleConnection(ctx context.Context, connection net.Conn, handler TCPHandler) {
 	request := make([]byte, s.messageSize)
+	// the buffer fits a message, so an unframed message is still returned by a single read
+	reader := bufio.NewReaderSize(connection, s.messageSize)
+
+	conn := &serverConnection{Conn: connection, idleTimeout: s.idleTimeout}
+	connCtx := context.WithValue(context.WithValue(ctx, pusherCtxKey{}, Pusher(conn)), framedCtxKey{}, conn)
+	connCtx, cancel := context.WithCancel(connCtx)
 
 	for {
-		if err := connection.SetDeadline(time.Now().Add(s.idleTimeout)); err != nil {
+		// held connections wait for requests without a deadline, the server pushes messages to them meanwhile
+		var deadline time.Time
+		if conn.holds.Load() == 0 {
+			deadline = time.Now().Add(s.idleTimeout)
+		}
+
+		if err := connection.SetReadDeadline(deadline); err != nil {
 			s.logger.Warn().Err(err).Msg("failed to set read deadline")
 
 			break
 		}
 
-		count, err := s.connRead(ctx, connection, request)
+		message, err := connRead(ctx, connection, func() ([]byte, error) {
+			return s.readMessage(conn, reader, request)
+		})
 		if err != nil {
 			if err != io.EOF {
 				s.logger.Warn().Err(err).Msg("failed to read")
@@ -125,29 +182,37 @@ func (s *TCPServer) handleConnection(ctx context.Context, connection net.Conn, h
 			break
 		}
 
+		// the handshake was handled
+		if message == nil {
+			continue
+		}
+
 		// Validate message size
-		if count > s.messageSize {
+		if len(message) > s.messageSize {
 			s.logger.Warn().
-				Int("received_size", count).
+				Int("received_size", len(message)).
 				Int("max_size", s.messageSize).
 				Msg("message size exceeds maximum, closing connection")
 			break
 		}
 
-		response, err := handler(ctx, request[:count])
+		response, err := handler(connCtx, message)
 		if err != nil {
 			s.logger.Error().Err(err).Msg("handler failed")
 
 			break
 		}
 
-		if _, err := connection.Write(response); err != nil {
+		if err := conn.Push(response); err != nil {
 			s.logger.Warn().Err(err).Msg("failed to write")
 
 			break
 		}
 	}
 
+	// stop pushing before closing the connection
+	cancel()
+
 	s.logger.Warn().Msg("close connection")
 
 	if err := connection.Close(); err != nil {
@@ -155,27 +220,119 @@ func (s *TCPServer) handleConnection(ctx context.Context, connection net.Conn, h
 	}
 }
 
-func (s *TCPServer) connRead(ctx context.Context, conn net.Conn, buff []byte) (int, error) {
+// readMessage reads a request of the connection. A handshake in the first message switches
+// the connection to the framed protocol, the handshake is confirmed and nil is returned for it.
+func (s *TCPServer) readMessage(conn *serverConnection, reader *bufio.Reader, buff []byte) ([]byte, error) {
+	if conn.framed.Load() {
+		return readFrame(reader, buff, s.messageSize)
+	}
+
+	if !conn.started {
+		conn.started = true
+
+		first, err := reader.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if first[0] == handshakeMagic[0] {
+			return nil, s.acceptHandshake(conn, reader)
+		}
+	}
+
+	count, err := reader.Read(buff)
+	if err != nil {
+		return nil, err
+	}
+
+	return buff[:count], nil
+}
+
+// acceptHandshake confirms the handshake and switches the connection to frames.
+func (s *TCPServer) acceptHandshake(conn *serverConnection, reader *bufio.Reader) error {
+	handshake := make([]byte, handshakeSize)
+	if _, err := io.ReadFull(reader, handshake); err != nil {
+		return err
+	}
+
+	version, err := parseHandshake(handshake)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Push(makeHandshake(version)); err != nil {
+		return err
+	}
+
+	conn.framed.Store(true)
+
+	return nil
+}
+
+// connRead runs a read of the connection, which is interrupted when the context is canceled.
+func connRead[T any](ctx context.Context, conn net.Conn, read func() (T, error)) (T, error) {
 	type readResult struct {
-		n   int
-		err error
+		message T
+		err     error
 	}
 
-	result := make(chan readResult)
+	// buffered, so the reading goroutine doesn't leak when the context is canceled
+	result := make(chan readResult, 1)
 
 	go func() {
 		defer close(result)
 
-		n, err := conn.Read(buff)
-		result <- readResult{n: n, err: err}
+		message, err := read()
+		result <- readResult{message: message, err: err}
 	}()
 
 	select {
 	case <-ctx.Done():
 		_ = conn.SetReadDeadline(time.Now())
 
-		return 0, ctx.Err()
+		var empty T
+
+		return empty, ctx.Err()
 	case res := <-result:
-		return res.n, nil
+		return res.message, res.err
+	}
+}
+
+// serverConnection serializes responses and messages pushed to a connection.
+type serverConnection struct {
+	net.Conn
+	idleTimeout time.Duration
+	writeMu     sync.Mutex
+	holds       atomic.Int64
+	started     bool        // the first message was read
+	framed      atomic.Bool // the handshake switched the connection to frames
+}
+
+func (c *serverConnection) Push(message []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.SetWriteDeadline(time.Now().Add(c.idleTimeout)); err != nil {
+		return err
+	}
+
+	if c.framed.Load() {
+		message = makeFrame(message)
+	}
+
+	_, err := c.Write(message)
+
+	return err
+}
+
+func (c *serverConnection) Hold() func() {
+	c.holds.Add(1)
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			c.holds.Add(-1)
+		})
 	}
 }
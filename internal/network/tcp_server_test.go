@@ -2,6 +2,7 @@ package network
 
 import (
 	"context"
+	"io"
 	"net"
 	"reflect"
 	"testing"
@@ -30,10 +31,13 @@ func TestTCPServer(t *testing.T) {
 	go func() {
 		require.NoError(t, server.HandleQueries(ctx, func(ctx context.Context, buffer []byte) ([]byte, error) {
 			require.True(t, reflect.DeepEqual([]byte(request), buffer))
+			require.False(t, IsFramed(ctx))
 			return []byte(response), nil
 		}))
 	}()
 
+	time.Sleep(100 * time.Millisecond)
+
 	connection, err := net.Dial("tcp", "localhost:20001")
 	require.NoError(t, err)
 
@@ -45,3 +49,114 @@ func TestTCPServer(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, reflect.DeepEqual([]byte(response), buffer[:count]))
 }
+
+func TestTCPServerFramed(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := zerolog.Nop()
+	server, err := NewTCPServer(":20003", 10, 2048, time.Minute, &logger)
+	require.NoError(t, err)
+
+	go func() {
+		require.NoError(t, server.HandleQueries(ctx, func(ctx context.Context, buffer []byte) ([]byte, error) {
+			require.True(t, IsFramed(ctx))
+
+			return append([]byte("re:"), buffer...), nil
+		}))
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	connection, err := net.Dial("tcp", "localhost:20003")
+	require.NoError(t, err)
+
+	_, err = connection.Write(makeHandshake(FrameVersion))
+	require.NoError(t, err)
+
+	handshake := make([]byte, handshakeSize)
+	_, err = io.ReadFull(connection, handshake)
+	require.NoError(t, err)
+	require.Equal(t, makeHandshake(FrameVersion), handshake)
+
+	// coalesced frames are separate requests
+	_, err = connection.Write(append(makeFrame([]byte("first")), makeFrame([]byte("second"))...))
+	require.NoError(t, err)
+
+	// a split frame is one request
+	frame := makeFrame([]byte("third"))
+	_, err = connection.Write(frame[:3])
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = connection.Write(frame[3:])
+	require.NoError(t, err)
+
+	for _, expected := range []string{"re:first", "re:second", "re:third"} {
+		response, err := readFrame(connection, nil, 2048)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(response))
+	}
+
+	// frames over the max message size close the connection
+	_, err = connection.Write(makeFrame(make([]byte, 4096)))
+	require.NoError(t, err)
+	_, err = readFrame(connection, nil, 2048)
+	require.Error(t, err)
+}
+
+func TestTCPServerPush(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	idleTimeout := 100 * time.Millisecond
+	logger := zerolog.Nop()
+	server, err := NewTCPServer(":20002", 10, 2048, idleTimeout, &logger)
+	require.NoError(t, err)
+
+	closed := make(chan struct{})
+	go func() {
+		require.NoError(t, server.HandleQueries(ctx, func(ctx context.Context, buffer []byte) ([]byte, error) {
+			pusher, ok := PusherFromContext(ctx)
+			require.True(t, ok)
+
+			release := pusher.Hold()
+			go func() {
+				defer release()
+
+				// the held connection outlives the idle timeout
+				time.Sleep(2 * idleTimeout)
+				require.NoError(t, pusher.Push([]byte("event")))
+
+				<-ctx.Done()
+				close(closed)
+			}()
+
+			return []byte("subscribed"), nil
+		}))
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	client, err := NewTCPClient("127.0.0.1:20002", 2048, time.Minute)
+	require.NoError(t, err)
+
+	response, err := client.Send(context.Background(), []byte("subscribe"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("subscribed"), response)
+
+	message, err := client.Receive(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []byte("event"), message)
+
+	// the context of requests is canceled when the connection is closed
+	require.NoError(t, client.Close())
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("request context is not canceled")
+	}
+}